@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// AccountResult — результат запроса для одного аккаунта
+type AccountResult[T any] struct {
+	Account string
+	Value   T
+	Err     error
+}
+
+// AccountManager — набор авторизованных клиентов, по одному на аккаунт.
+// У каждого клиента свой токен и свой Circuit Breaker.
+type AccountManager struct {
+	opts    []Option
+	clients map[string]*AuthorizedDaichiClient
+	mu      sync.RWMutex
+}
+
+// NewAccountManager — создает менеджер; опции применяются к каждому аккаунту.
+// Хранилище токена и Circuit Breaker задаются в опциях AddAccount: если экземпляр
+// уже использует клиент другого аккаунта, AddAccount вернет ErrSharedAccountState.
+func NewAccountManager(opts ...Option) *AccountManager {
+	return &AccountManager{
+		opts:    opts,
+		clients: make(map[string]*AuthorizedDaichiClient),
+	}
+}
+
+// AddAccount — авторизует аккаунт и добавляет его под ключом account
+func (m *AccountManager) AddAccount(ctx context.Context, account, username, password string, opts ...Option) error {
+	return m.addAccount(account, func(opts []Option) (*AuthorizedDaichiClient, error) {
+		return NewAuthorizedDaichiClient(ctx, username, password, opts...)
+	}, opts)
+}
+
+// AddAccountWithProvider — авторизует аккаунт с источником учетных данных
+func (m *AccountManager) AddAccountWithProvider(ctx context.Context, account string, provider CredentialsProvider, opts ...Option) error {
+	return m.addAccount(account, func(opts []Option) (*AuthorizedDaichiClient, error) {
+		return NewAuthorizedDaichiClientWithProvider(ctx, provider, opts...)
+	}, opts)
+}
+
+// addAccount — создает клиент с общими и собственными опциями аккаунта
+func (m *AccountManager) addAccount(account string, create func([]Option) (*AuthorizedDaichiClient, error), opts []Option) error {
+	all := append(append([]Option{}, m.opts...), opts...)
+
+	// Проверка до входа: опции применяются к клиенту без запросов к API
+	m.mu.RLock()
+	err := m.sharedState(account, NewDaichiClient(all...))
+	m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("account %s: %w", account, err)
+	}
+
+	client, err := create(all)
+	if err != nil {
+		return fmt.Errorf("account %s: %w", account, err)
+	}
+
+	// Повторная проверка: параллельный AddAccount мог занять те же экземпляры
+	m.mu.Lock()
+	if err := m.sharedState(account, client.DaichiClient); err != nil {
+		m.mu.Unlock()
+		client.Close()
+		return fmt.Errorf("account %s: %w", account, err)
+	}
+	previous := m.clients[account]
+	m.clients[account] = client
+	m.mu.Unlock()
+
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+// sharedState — проверяет, что хранилище токена и Circuit Breaker клиента не используются
+// клиентами других аккаунтов. Вызывается под m.mu.
+func (m *AccountManager) sharedState(account string, c *DaichiClient) error {
+	store, storeOK := instanceOf(c.tokenStore)
+	for other, client := range m.clients {
+		if other == account {
+			continue
+		}
+		if client.breaker == c.breaker {
+			return fmt.Errorf("%w: WithCircuitBreaker is also used by account %s", ErrSharedAccountState, other)
+		}
+		if existing, ok := instanceOf(client.tokenStore); ok && storeOK && existing == store {
+			return fmt.Errorf("%w: WithTokenStore is also used by account %s", ErrSharedAccountState, other)
+		}
+	}
+	return nil
+}
+
+// instanceOf — идентичность хранилища: адрес указателя, map, канала или среза.
+// У значений других типов собственного состояния нет: каждый клиент получает копию.
+func instanceOf(store TokenStore) (uintptr, bool) {
+	if store == nil {
+		return 0, false
+	}
+	v := reflect.ValueOf(store)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.Slice, reflect.UnsafePointer:
+		return v.Pointer(), !v.IsNil()
+	}
+	return 0, false
+}
+
+// AddClient — добавляет готовый клиент; прежний клиент аккаунта закрывается
+func (m *AccountManager) AddClient(account string, client *AuthorizedDaichiClient) {
+	m.mu.Lock()
+	previous := m.clients[account]
+	m.clients[account] = client
+	m.mu.Unlock()
+
+	if previous != nil && previous != client {
+		previous.Close()
+	}
+}
+
+// Client — возвращает клиент аккаунта
+func (m *AccountManager) Client(account string) (*AuthorizedDaichiClient, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	client, ok := m.clients[account]
+	return client, ok
+}
+
+// Remove — удаляет аккаунт и останавливает его фоновое обновление токена
+func (m *AccountManager) Remove(account string) {
+	m.mu.Lock()
+	client := m.clients[account]
+	delete(m.clients, account)
+	m.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+}
+
+// Accounts — возвращает ключи аккаунтов в отсортированном порядке
+func (m *AccountManager) Accounts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := make([]string, 0, len(m.clients))
+	for account := range m.clients {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// Close — закрывает все клиенты
+func (m *AccountManager) Close() {
+	m.mu.Lock()
+	clients := m.clients
+	m.clients = make(map[string]*AuthorizedDaichiClient)
+	m.mu.Unlock()
+
+	for _, client := range clients {
+		client.Close()
+	}
+}
+
+// FanOut — выполняет fn параллельно для всех аккаунтов.
+// Ошибка одного аккаунта не прерывает остальные; результаты отсортированы по аккаунту.
+func FanOut[T any](ctx context.Context, m *AccountManager, fn func(context.Context, *AuthorizedDaichiClient) (T, error)) []AccountResult[T] {
+	accounts := m.Accounts()
+	results := make([]AccountResult[T], len(accounts))
+
+	var wg sync.WaitGroup
+	for i, account := range accounts {
+		client, ok := m.Client(account)
+		if !ok {
+			results[i] = AccountResult[T]{Account: account, Err: fmt.Errorf("account %s was removed", account)}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, account string, client *AuthorizedDaichiClient) {
+			defer wg.Done()
+			value, err := fn(ctx, client)
+			results[i] = AccountResult[T]{Account: account, Value: value, Err: err}
+		}(i, account, client)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// GetBuildings — возвращает здания всех аккаунтов
+func (m *AccountManager) GetBuildings(ctx context.Context) []AccountResult[[]DaichiBuilding] {
+	return FanOut(ctx, m, func(ctx context.Context, c *AuthorizedDaichiClient) ([]DaichiBuilding, error) {
+		return c.GetBuildings(ctx)
+	})
+}
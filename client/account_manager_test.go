@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newAccountsAPI — API, которое выдает каждому логину свой токен
+func newAccountsAPI(t *testing.T, logins *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			logins.Add(1)
+			fmt.Fprintf(w, `{"done":true,"data":{"access_token":"token-%s"}}`, r.FormValue("email"))
+			return
+		}
+		fmt.Fprintf(w, `{"done":true,"data":[{"id":1,"title":%q}]}`, r.Header.Get("Authorization"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// valueTokenStore — хранилище-значение без общего состояния
+type valueTokenStore struct{ name string }
+
+func (valueTokenStore) Load(context.Context) (*StoredToken, error) { return nil, ErrTokenNotStored }
+func (valueTokenStore) Save(context.Context, *StoredToken) error   { return nil }
+func (valueTokenStore) Clear(context.Context) error                { return nil }
+
+// mapTokenStore — несравнимое хранилище с общим состоянием
+type mapTokenStore map[string]*StoredToken
+
+func (mapTokenStore) Load(context.Context) (*StoredToken, error) { return nil, ErrTokenNotStored }
+func (mapTokenStore) Save(context.Context, *StoredToken) error   { return nil }
+func (mapTokenStore) Clear(context.Context) error                { return nil }
+
+func TestAccountManagerRejectsSharedState(t *testing.T) {
+	var logins atomic.Int32
+	srv := newAccountsAPI(t, &logins)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{Name: "shared", Timeout: time.Second})
+	fileStore := WithTokenStore(NewFileTokenStore(filepath.Join(t.TempDir(), "token.json")))
+	mapStore := WithTokenStore(mapTokenStore{})
+
+	tests := []struct {
+		name   string
+		shared []Option // Опции NewAccountManager
+		a, b   []Option // Опции AddAccount
+		want   error
+	}{
+		{"shared token store", []Option{fileStore}, nil, nil, ErrSharedAccountState},
+		{"shared circuit breaker", []Option{WithCircuitBreaker(breaker)}, nil, nil, ErrSharedAccountState},
+		{"same store per account", nil, []Option{fileStore}, []Option{fileStore}, ErrSharedAccountState},
+		{"same incomparable store", nil, []Option{mapStore}, []Option{mapStore}, ErrSharedAccountState},
+		{"equal value stores", []Option{WithTokenStore(valueTokenStore{name: "same"})}, nil, nil, nil},
+		{"own stores", nil, []Option{WithTokenStore(mapTokenStore{})}, []Option{WithTokenStore(mapTokenStore{})}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewAccountManager(append([]Option{WithBaseURL(srv.URL), WithNoLogs()}, tt.shared...)...)
+			defer m.Close()
+			ctx := context.Background()
+
+			if err := m.AddAccount(ctx, "a", "a@example.com", "password", tt.a...); err != nil {
+				t.Fatalf("first AddAccount: %v", err)
+			}
+			// Повторное добавление того же аккаунта заменяет его клиент
+			if err := m.AddAccount(ctx, "a", "a@example.com", "password", tt.a...); err != nil {
+				t.Fatalf("replacing account a: %v", err)
+			}
+			err := m.AddAccount(ctx, "b", "b@example.com", "password", tt.b...)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("second AddAccount err = %v, want %v", err, tt.want)
+			}
+			if _, ok := m.Client("b"); ok != (tt.want == nil) {
+				t.Errorf("account b added = %v, want %v", ok, tt.want == nil)
+			}
+		})
+	}
+}
+
+func TestAccountManagerPerAccountTokenStores(t *testing.T) {
+	var logins atomic.Int32
+	srv := newAccountsAPI(t, &logins)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	m := NewAccountManager(WithBaseURL(srv.URL), WithNoLogs())
+	defer m.Close()
+	for _, account := range []string{"a", "b"} {
+		store := WithTokenStore(NewFileTokenStore(filepath.Join(dir, account+".json")))
+		if err := m.AddAccount(ctx, account, account+"@example.com", "password", store); err != nil {
+			t.Fatalf("AddAccount %s: %v", account, err)
+		}
+	}
+
+	for _, r := range m.GetBuildings(ctx) {
+		want := "Bearer token-" + r.Account + "@example.com"
+		if r.Err != nil || r.Value[0].Title != want {
+			t.Errorf("%s: buildings = %+v, err = %v, want token %s", r.Account, r.Value, r.Err, want)
+		}
+	}
+}
+
+func TestStoredTokenOfAnotherAccountIsNotReused(t *testing.T) {
+	var logins atomic.Int32
+	srv := newAccountsAPI(t, &logins)
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	ctx := context.Background()
+
+	login := func(username string) *AuthorizedDaichiClient {
+		t.Helper()
+		c, err := NewAuthorizedDaichiClient(ctx, username, "password", WithBaseURL(srv.URL), WithNoLogs(), WithTokenStore(store))
+		if err != nil {
+			t.Fatalf("NewAuthorizedDaichiClient %s: %v", username, err)
+		}
+		t.Cleanup(c.Close)
+		return c
+	}
+
+	login("a@example.com")
+	b := login("b@example.com")
+	if got, want := b.currentToken(), "token-b@example.com"; got != want {
+		t.Errorf("token = %s, want %s", got, want)
+	}
+
+	stored, err := store.Load(ctx)
+	if err != nil || stored.Account != "b@example.com" {
+		t.Errorf("stored = %+v, err = %v, want account b@example.com", stored, err)
+	}
+
+	login("b@example.com")
+	if n := logins.Load(); n != 2 {
+		t.Errorf("logins = %d, want 2 (stored token of the same account reused)", n)
+	}
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// APIError — ошибка, возвращенная API (HTTP-статус и содержимое поля errors)
+type APIError struct {
+	StatusCode  int
+	Codes       []string
+	Messages    []string
+	FieldErrors map[string][]string
+	RequestID   string
+	Raw         json.RawMessage // Исходное содержимое поля errors
+}
+
+// Error реализует интерфейс error
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("API error (status %d)", e.StatusCode))
+	if len(e.Messages) > 0 {
+		sb.WriteString(": " + strings.Join(e.Messages, "; "))
+	}
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		sb.WriteString(fmt.Sprintf("; %s: %s", field, strings.Join(e.FieldErrors[field], ", ")))
+	}
+	if len(e.Codes) > 0 {
+		sb.WriteString(fmt.Sprintf(" [%s]", strings.Join(e.Codes, ", ")))
+	}
+	if e.RequestID != "" {
+		sb.WriteString(" (request id " + e.RequestID + ")")
+	}
+	return sb.String()
+}
+
+// Is позволяет сравнивать APIError с sentinel-ошибками из errors.go
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRequestFailed:
+		return true
+	case ErrEndpointNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrMethodNotAllowed:
+		return e.StatusCode == http.StatusMethodNotAllowed
+	case ErrTokenExpired:
+		return e.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
+
+// IsRetryable — можно ли повторить запрос, завершившийся этой ошибкой
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, ErrTransportUnavailable) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsAuthError — связана ли ошибка с авторизацией
+func IsAuthError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return true
+	}
+	return errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrTokenRefreshFailed) ||
+		errors.Is(err, ErrMissingCredentials)
+}
+
+// apiErrorFromResponse — читает тело ответа и создает APIError
+func apiErrorFromResponse(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return newAPIError(resp.StatusCode, resp.Header, body)
+}
+
+// newAPIError — создает APIError из тела ответа в формате APIResponse
+func newAPIError(statusCode int, header http.Header, body []byte) *APIError {
+	var envelope struct {
+		Errors    json.RawMessage `json:"errors"`
+		RequestID string          `json:"requestId"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		apiErr := &APIError{StatusCode: statusCode, RequestID: requestID(header)}
+		if text := strings.TrimSpace(string(body)); text != "" {
+			apiErr.Messages = []string{redactText(text)}
+		}
+		return apiErr
+	}
+
+	apiErr := decodeAPIErrors(statusCode, envelope.Errors)
+	apiErr.RequestID = envelope.RequestID
+	if id := requestID(header); id != "" {
+		apiErr.RequestID = id
+	}
+	return apiErr
+}
+
+// requestID — идентификатор запроса из заголовков ответа
+func requestID(header http.Header) string {
+	if header == nil {
+		return ""
+	}
+	if id := header.Get("X-Request-Id"); id != "" {
+		return id
+	}
+	return header.Get("X-Correlation-Id")
+}
+
+// decodeAPIErrors — разбирает поле errors: строку, список или объект
+func decodeAPIErrors(statusCode int, raw json.RawMessage) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	if len(raw) == 0 || string(raw) == "null" {
+		return apiErr
+	}
+	apiErr.Raw = redactJSON(raw)
+
+	var payload any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		apiErr.Messages = []string{redactText(string(raw))}
+		return apiErr
+	}
+	apiErr.collect("", payload)
+	return apiErr
+}
+
+// collect — раскладывает значения errors по кодам, сообщениям и ошибкам полей
+func (e *APIError) collect(field string, value any) {
+	switch v := value.(type) {
+	case string:
+		e.addMessage(field, v)
+
+	case []any:
+		for _, item := range v {
+			e.collect(field, item)
+		}
+
+	case map[string]any:
+		code, hasCode := v["code"]
+		message, hasMessage := v["message"]
+		if hasCode || hasMessage {
+			if hasCode && code != nil {
+				e.Codes = append(e.Codes, fmt.Sprint(code))
+			}
+			if s, ok := message.(string); ok {
+				if f, ok := v["field"].(string); ok {
+					field = f
+				}
+				e.addMessage(field, s)
+			}
+			return
+		}
+		for key, item := range v {
+			e.collect(key, item)
+		}
+
+	case nil:
+
+	default:
+		e.addMessage(field, fmt.Sprint(v))
+	}
+}
+
+// addMessage — добавляет общее сообщение или ошибку поля; значения секретных полей скрываются
+func (e *APIError) addMessage(field, message string) {
+	if sensitiveKeys[strings.ToLower(field)] {
+		message = redactSecret(message)
+	}
+	if field == "" {
+		e.Messages = append(e.Messages, message)
+		return
+	}
+	if e.FieldErrors == nil {
+		e.FieldErrors = make(map[string][]string)
+	}
+	e.FieldErrors[field] = append(e.FieldErrors[field], message)
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrRequestFailed, ErrEndpointNotFound, ErrMethodNotAllowed, ErrTokenExpired, ErrInvalidValue}
+
+	tests := []struct {
+		status int
+		want   []error // Sentinel-ошибки, которым соответствует статус
+	}{
+		{http.StatusBadRequest, []error{ErrRequestFailed}},
+		{http.StatusUnauthorized, []error{ErrRequestFailed, ErrTokenExpired}},
+		{http.StatusForbidden, []error{ErrRequestFailed}},
+		{http.StatusNotFound, []error{ErrRequestFailed, ErrEndpointNotFound}},
+		{http.StatusMethodNotAllowed, []error{ErrRequestFailed, ErrMethodNotAllowed}},
+		{http.StatusInternalServerError, []error{ErrRequestFailed}},
+	}
+
+	for _, tt := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: tt.status})
+		for _, sentinel := range sentinels {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == sentinel
+			}
+			if got := errors.Is(err, sentinel); got != want {
+				t.Errorf("status %d: errors.Is(%v) = %v, want %v", tt.status, sentinel, got, want)
+			}
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"400", &APIError{StatusCode: http.StatusBadRequest}, false},
+		{"401", &APIError{StatusCode: http.StatusUnauthorized}, false},
+		{"404", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", &APIError{StatusCode: http.StatusInternalServerError}, true},
+		{"503 wrapped", fmt.Errorf("get state: %w", &APIError{StatusCode: http.StatusServiceUnavailable}), true},
+		{"network", &net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{"transport unavailable", ErrTransportUnavailable, true},
+		{"validation", &ValidationError{Field: FunctionNameMode, Reason: "unknown mode"}, false},
+		{"canceled", context.Canceled, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"401", &APIError{StatusCode: http.StatusUnauthorized}, true},
+		{"403", &APIError{StatusCode: http.StatusForbidden}, true},
+		{"404", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"500", &APIError{StatusCode: http.StatusInternalServerError}, false},
+		{"token expired", ErrTokenExpired, true},
+		{"refresh failed", fmt.Errorf("%w: timeout", ErrTokenRefreshFailed), true},
+		{"missing credentials", ErrMissingCredentials, true},
+		{"network", &net.DNSError{Err: "no such host"}, false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsAuthError(tt.err); got != tt.want {
+			t.Errorf("%s: IsAuthError = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewAPIErrorPayloads(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		want   *APIError
+	}{
+		{
+			"string",
+			`{"done":false,"errors":"device not found"}`,
+			nil,
+			&APIError{Messages: []string{"device not found"}},
+		},
+		{
+			"list of strings",
+			`{"done":false,"errors":["first","second"]}`,
+			nil,
+			&APIError{Messages: []string{"first", "second"}},
+		},
+		{
+			"code and message",
+			`{"done":false,"errors":[{"code":"E42","message":"busy"}],"requestId":"body-id"}`,
+			nil,
+			&APIError{Codes: []string{"E42"}, Messages: []string{"busy"}, RequestID: "body-id"},
+		},
+		{
+			"field errors",
+			`{"done":false,"errors":{"email":["is required","is invalid"]}}`,
+			http.Header{"X-Request-Id": {"header-id"}},
+			&APIError{FieldErrors: map[string][]string{"email": {"is required", "is invalid"}}, RequestID: "header-id"},
+		},
+		{
+			"message with field",
+			`{"done":false,"errors":[{"code":1,"message":"too high","field":"value"}]}`,
+			nil,
+			&APIError{Codes: []string{"1"}, FieldErrors: map[string][]string{"value": {"too high"}}},
+		},
+		{
+			"no errors",
+			`{"done":false}`,
+			nil,
+			&APIError{},
+		},
+		{
+			"non-JSON",
+			`Bad Gateway`,
+			nil,
+			&APIError{Messages: []string{"Bad Gateway"}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := newAPIError(http.StatusBadRequest, tt.header, []byte(tt.body))
+		got.Raw = nil
+		tt.want.StatusCode = http.StatusBadRequest
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: APIError = %#v, want %#v", tt.name, got, tt.want)
+		}
+	}
+}
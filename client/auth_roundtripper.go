@@ -2,22 +2,46 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 )
 
+// skipAuthKey — ключ контекста для запросов без авторизации (например, /token)
+type skipAuthKey struct{}
+
+// withoutAuth — помечает запрос как не требующий токена и обновления
+func withoutAuth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipAuthKey{}, true)
+}
+
 // AuthRoundTripper добавляет токен к каждому запросу
 type AuthRoundTripper struct {
-	Transport http.RoundTripper
-	Token     string
-	RefreshFn func(context.Context) (string, error)
-	Logger    *Logger
+	Transport   http.RoundTripper
+	Token       string
+	TokenSource func() string // Если задан, используется вместо Token
+	RefreshFn   func(context.Context) (string, error)
+	Logger      *Logger
+}
+
+// currentToken — возвращает актуальный токен
+func (rt *AuthRoundTripper) currentToken() string {
+	if rt.TokenSource != nil {
+		return rt.TokenSource()
+	}
+	return rt.Token
 }
 
 // RoundTrip реализует интерфейс http.RoundTripper
 func (rt *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	if rt.Token != "" {
-		req = req.Clone(req.Context())
-		req.Header.Set("Authorization", "Bearer "+rt.Token)
+	if skip, _ := req.Context().Value(skipAuthKey{}).(bool); skip {
+		return rt.Transport.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	sentToken := rt.currentToken()
+	if sentToken != "" {
+		req.Header.Set("Authorization", "Bearer "+sentToken)
 	}
 
 	resp, err := rt.Transport.RoundTrip(req)
@@ -26,33 +50,42 @@ func (rt *AuthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error)
 		return nil, err
 	}
 
-	// Если токен истек, обновляем его
+	// Если токен истек, обновляем его и повторяем запрос
 	if resp.StatusCode == http.StatusUnauthorized {
-		if rt.RefreshFn != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if rt.RefreshFn == nil {
+			return nil, ErrTokenExpired
+		}
+
+		// Токен уже обновлен другим запросом — повторяем с новым без обращения к /token
+		newToken := rt.currentToken()
+		if newToken == "" || newToken == sentToken {
 			rt.Logger.Warn("Token expired, refreshing...")
-			newToken, refreshErr := rt.RefreshFn(req.Context())
+			var refreshErr error
+			newToken, refreshErr = rt.RefreshFn(req.Context())
 			if refreshErr != nil {
 				rt.Logger.Error("Token refresh failed: %v", refreshErr)
-				return nil, ErrTokenRefreshFailed
+				return nil, fmt.Errorf("%w: %v", ErrTokenRefreshFailed, refreshErr)
 			}
-
-			req.Header.Set("Authorization", "Bearer "+newToken)
-			rt.Logger.Info("Token refreshed: %s", newToken)
-			return rt.Transport.RoundTrip(req)
+			rt.Logger.Info("Token refreshed: %s", redactSecret(newToken))
 		}
-		return resp, ErrTokenExpired
-	}
 
-	// Обработка 405 Method Not Allowed
-	if resp.StatusCode == http.StatusMethodNotAllowed {
-		rt.Logger.Error("Method Not Allowed (405): %s", req.URL.String())
-		return nil, ErrMethodNotAllowed
-	}
-
-	// Обработка 404 Not Found
-	if resp.StatusCode == http.StatusNotFound {
-		rt.Logger.Error("Endpoint Not Found (404): %s", req.URL.String())
-		return nil, ErrEndpointNotFound
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				rt.Logger.Error("Cannot replay request body: %s", req.URL.String())
+				return nil, ErrTokenExpired
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to replay request body: %w", err)
+			}
+			retry.Body = body
+		}
+		retry.Header.Set("Authorization", "Bearer "+newToken)
+		return rt.Transport.RoundTrip(retry)
 	}
 
 	return resp, nil
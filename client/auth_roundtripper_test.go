@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authAPI — сервер, который принимает только токен fresh и запоминает тела запросов
+type authAPI struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	bodies []string
+}
+
+func newAuthAPI(t *testing.T) *authAPI {
+	t.Helper()
+	api := &authAPI{}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		api.mu.Lock()
+		api.bodies = append(api.bodies, string(body))
+		api.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+// roundTripper — AuthRoundTripper со старым токеном, который обновляется на fresh
+func (api *authAPI) roundTripper() *AuthRoundTripper {
+	token := "stale"
+	return &AuthRoundTripper{
+		Transport:   http.DefaultTransport,
+		TokenSource: func() string { return token },
+		RefreshFn: func(context.Context) (string, error) {
+			token = "fresh"
+			return token, nil
+		},
+		Logger: NewLogger(LogNone, nil),
+	}
+}
+
+func TestAuthReplaysBodyAfterRefresh(t *testing.T) {
+	api := newAuthAPI(t)
+	const payload = `{"cmdId":1,"value":{"functionId":350,"isOn":true}}`
+
+	req, _ := http.NewRequest(http.MethodPost, api.URL, strings.NewReader(payload))
+	resp, err := api.roundTripper().RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.bodies) != 2 || api.bodies[0] != payload || api.bodies[1] != payload {
+		t.Errorf("bodies = %q, want the payload sent twice unchanged", api.bodies)
+	}
+}
+
+func TestAuthDoesNotReplayBodyWithoutGetBody(t *testing.T) {
+	api := newAuthAPI(t)
+
+	req, _ := http.NewRequest(http.MethodPost, api.URL, strings.NewReader(`{"cmdId":1}`))
+	req.GetBody = nil
+	if _, err := api.roundTripper().RoundTrip(req); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("RoundTrip err = %v, want ErrTokenExpired", err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.bodies) != 1 {
+		t.Errorf("requests = %d, want 1 (body must not be replayed)", len(api.bodies))
+	}
+}
@@ -13,15 +13,34 @@ type AuthorizedDaichiClient struct {
 // NewAuthorizedDaichiClient — создает авторизованный клиент
 func NewAuthorizedDaichiClient(ctx context.Context, username, password string, opts ...Option) (*AuthorizedDaichiClient, error) {
 	opts = append(opts, WithUsername(username), WithPassword(password))
+	return newAuthorizedDaichiClient(ctx, opts...)
+}
+
+// NewAuthorizedDaichiClientWithProvider — создает авторизованный клиент с источником учетных данных
+func NewAuthorizedDaichiClientWithProvider(ctx context.Context, provider CredentialsProvider, opts ...Option) (*AuthorizedDaichiClient, error) {
+	opts = append(opts, WithCredentialsProvider(provider))
+	return newAuthorizedDaichiClient(ctx, opts...)
+}
+
+// newAuthorizedDaichiClient — создает клиент и выполняет вход
+func newAuthorizedDaichiClient(ctx context.Context, opts ...Option) (*AuthorizedDaichiClient, error) {
 	client := NewDaichiClient(opts...)
+	if err := client.Validate(); err != nil {
+		client.Logger.Error("Invalid client configuration: %v", err)
+		return nil, err
+	}
 
 	authCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	client.Logger.Info("Authenticating...")
-	if err := client.GetToken(authCtx); err != nil {
-		client.Logger.Error("Authentication failed: %v", err)
-		return nil, err
+	// Сохраненный токен используется повторно; если сервер его отклонит,
+	// AuthRoundTripper выполнит вход по паролю
+	if !client.loadStoredToken(authCtx) {
+		client.Logger.Info("Authenticating...")
+		if err := client.GetToken(authCtx); err != nil {
+			client.Logger.Error("Authentication failed: %v", err)
+			return nil, err
+		}
 	}
 
 	client.Logger.Info("Authorized client created")
@@ -47,3 +66,26 @@ func (c *AuthorizedDaichiClient) GetDeviceState(ctx context.Context, deviceID in
 	c.Logger.Info("Fetching device state: %d", deviceID)
 	return c.DaichiClient.GetDeviceState(ctx, deviceID)
 }
+
+// ControlDevice — отправляет команду управления устройству
+func (c *AuthorizedDaichiClient) ControlDevice(ctx context.Context, deviceID int, control DeviceControlRequest) (*DaichiBuildingDeviceStruct, error) {
+	c.Logger.Info("Sending control command to device %d: function %d", deviceID, control.Value.FunctionID)
+	return c.DaichiClient.ControlDevice(ctx, deviceID, control)
+}
+
+// GetDeviceFunctions — возвращает каталог функций устройства
+func (c *AuthorizedDaichiClient) GetDeviceFunctions(ctx context.Context, deviceID int) (*DeviceFunctionCatalog, error) {
+	c.Logger.Info("Fetching device functions: %d", deviceID)
+	return c.DaichiClient.GetDeviceFunctions(ctx, deviceID)
+}
+
+// ResolveConflict — повторяет команду с выбранным вариантом разрешения конфликта
+func (c *AuthorizedDaichiClient) ResolveConflict(ctx context.Context, err error, choice ConflictResolveVariant) (*DaichiBuildingDeviceStruct, error) {
+	return c.DaichiClient.ResolveConflict(ctx, err, choice)
+}
+
+// ControlDeviceAndWait — отправляет команду и ждет ее применения устройством
+func (c *AuthorizedDaichiClient) ControlDeviceAndWait(ctx context.Context, deviceID int, control DeviceControlRequest, opts WaitOptions) (*DaichiBuildingDeviceStruct, error) {
+	c.Logger.Info("Sending control command to device %d and waiting for confirmation", deviceID)
+	return c.DaichiClient.ControlDeviceAndWait(ctx, deviceID, control, opts)
+}
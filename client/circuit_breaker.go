@@ -1,6 +1,11 @@
 package client
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/savier89/circuitbreaker"
@@ -12,16 +17,193 @@ type CircuitBreakerConfig struct {
 	MaxRequests uint32
 	Interval    time.Duration
 	Timeout     time.Duration
-	IsError     func(error) bool
+	IsError     func(error) bool // nil — сбоем считается любая ошибка
 }
 
-// NewCircuitBreaker — создаёт новый Circuit Breaker
+// NewCircuitBreaker — создаёт новый Circuit Breaker.
+// Отмена или дедлайн контекста вызывающего никогда не считаются сбоем, даже если IsError их не исключает;
+// тайм-аут самого http.Client считается.
 func NewCircuitBreaker(cfg CircuitBreakerConfig) *circuitbreaker.CircuitBreaker {
+	isError := cfg.IsError
+	if isError == nil {
+		isError = func(err error) bool { return err != nil }
+	}
+
 	return circuitbreaker.NewCircuitBreaker(circuitbreaker.Config{
 		Name:        cfg.Name,
 		MaxRequests: cfg.MaxRequests,
 		Interval:    cfg.Interval,
 		Timeout:     cfg.Timeout,
-		IsError:     cfg.IsError,
+		IsError: func(err error) bool {
+			var canceled *callerCanceledError
+			if errors.As(err, &canceled) {
+				return false
+			}
+			return isError(err)
+		},
 	})
 }
+
+// callerContextKey — ключ контекста с исходным контекстом вызывающего.
+// http.Client добавляет к контексту запроса свой тайм-аут, поэтому без исходного контекста
+// отмену вызывающим нельзя отличить от медленного сервера.
+type callerContextKey struct{}
+
+// do — выполняет запрос, сохраняя контекст вызывающего для Circuit Breaker
+func (c *DaichiClient) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	return c.httpClient.Do(req.WithContext(context.WithValue(ctx, callerContextKey{}, ctx)))
+}
+
+// canceledByCaller — завершился ли контекст вызывающего
+func canceledByCaller(req *http.Request) bool {
+	if caller, ok := req.Context().Value(callerContextKey{}).(context.Context); ok {
+		return caller.Err() != nil
+	}
+	return errors.Is(req.Context().Err(), context.Canceled)
+}
+
+// callerCanceledError — запрос прерван вызывающим; Circuit Breaker не считает его сбоем
+type callerCanceledError struct {
+	err error
+}
+
+// Error реализует интерфейс error
+func (e *callerCanceledError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap возвращает исходную ошибку транспорта
+func (e *callerCanceledError) Unwrap() error {
+	return e.err
+}
+
+// errServerStatus — ответ 5xx, который должен учитываться Circuit Breaker как сбой
+type errServerStatus struct {
+	resp *http.Response
+}
+
+// Error реализует интерфейс error
+func (e *errServerStatus) Error() string {
+	return fmt.Sprintf("server error: %d", e.resp.StatusCode)
+}
+
+// breakerRoundTripper выполняет каждый запрос через Circuit Breaker.
+// Сетевые ошибки и ответы 5xx считаются сбоями, ответы 4xx и отмена вызывающим — нет.
+type breakerRoundTripper struct {
+	transport http.RoundTripper
+	breaker   *circuitbreaker.CircuitBreaker
+	stats     *breakerStats
+	logger    *Logger
+}
+
+// RoundTrip реализует интерфейс http.RoundTripper
+func (rt *breakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if canceledByCaller(req) {
+		return nil, req.Context().Err()
+	}
+
+	executed := false
+	result, err := rt.breaker.Execute(func() (interface{}, error) {
+		executed = true
+		resp, err := rt.transport.RoundTrip(req)
+		if err != nil {
+			if canceledByCaller(req) {
+				return nil, &callerCanceledError{err: err}
+			}
+			return nil, err
+		}
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return nil, &errServerStatus{resp: resp}
+		}
+		return resp, nil
+	})
+
+	if !executed {
+		rt.stats.rejected()
+		rt.logger.Error("Circuit breaker rejected request: %s: %v", req.URL.String(), err)
+		return nil, fmt.Errorf("%w: %v", ErrCircuitBreakerOpen, err)
+	}
+
+	var canceled *callerCanceledError
+	if errors.As(err, &canceled) {
+		rt.stats.canceled()
+		return nil, canceled.err
+	}
+	var serverErr *errServerStatus
+	if errors.As(err, &serverErr) {
+		rt.stats.failed(err)
+		return serverErr.resp, nil
+	}
+	if err != nil {
+		rt.stats.failed(err)
+		return nil, err
+	}
+	rt.stats.succeeded()
+	return result.(*http.Response), nil
+}
+
+// BreakerHealth — состояние Circuit Breaker и счетчики запросов клиента
+type BreakerHealth struct {
+	State               circuitbreaker.State
+	Requests            uint64    // Запросы, отправленные через Circuit Breaker
+	Successes           uint64    // Ответы 1xx–4xx
+	Failures            uint64    // Сетевые ошибки и ответы 5xx
+	Rejected            uint64    // Запросы, отклоненные разомкнутым Circuit Breaker
+	Canceled            uint64    // Запросы, отмененные вызывающим (не считаются сбоями)
+	ConsecutiveFailures uint64    // Сбои подряд с последнего успешного ответа
+	LastFailure         time.Time // Время последнего сбоя
+	LastError           string    // Текст последнего сбоя
+}
+
+// breakerStats — счетчики breakerRoundTripper
+type breakerStats struct {
+	mu     sync.Mutex
+	health BreakerHealth
+}
+
+func (s *breakerStats) succeeded() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Requests++
+	s.health.Successes++
+	s.health.ConsecutiveFailures = 0
+}
+
+func (s *breakerStats) failed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Requests++
+	s.health.Failures++
+	s.health.ConsecutiveFailures++
+	s.health.LastFailure = time.Now()
+	s.health.LastError = err.Error()
+}
+
+func (s *breakerStats) rejected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Rejected++
+}
+
+func (s *breakerStats) canceled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health.Requests++
+	s.health.Canceled++
+}
+
+// BreakerState — возвращает текущее состояние Circuit Breaker
+func (c *DaichiClient) BreakerState() circuitbreaker.State {
+	return c.breaker.State()
+}
+
+// BreakerHealth — состояние Circuit Breaker и счетчики запросов этого клиента (для health-check и метрик)
+func (c *DaichiClient) BreakerHealth() BreakerHealth {
+	c.breakerStats.mu.Lock()
+	health := c.breakerStats.health
+	c.breakerStats.mu.Unlock()
+
+	health.State = c.breaker.State()
+	return health
+}
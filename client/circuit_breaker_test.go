@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSlowAPI — API, в котором запросы к устройствам отвечают с задержкой или 500 для устройства 500
+func newSlowAPI(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/token"):
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+		case strings.HasSuffix(path, "/devices/500"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprint(w, `{"done":true,"data":{"id":1}}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	srv := newSlowAPI(t, 200*time.Millisecond)
+	c, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password",
+		WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetDeviceState(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetDeviceState err = %v, want context.DeadlineExceeded", err)
+	}
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err := c.GetDeviceState(canceled, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetDeviceState err = %v, want context.Canceled", err)
+	}
+
+	health := c.BreakerHealth()
+	if health.Failures != 0 || health.ConsecutiveFailures != 0 {
+		t.Errorf("health = %+v, want no failures for caller cancellation", health)
+	}
+	if health.Canceled != 1 {
+		t.Errorf("Canceled = %d, want 1 (the second request never reached the breaker)", health.Canceled)
+	}
+}
+
+func TestBreakerCountsServerFailures(t *testing.T) {
+	srv := newSlowAPI(t, 200*time.Millisecond)
+	c, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password",
+		WithBaseURL(srv.URL), WithNoLogs(), WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if _, err := c.GetDeviceState(ctx, 1); err == nil {
+		t.Fatal("GetDeviceState succeeded despite client timeout")
+	}
+	if _, err := c.GetDeviceState(ctx, 500); err == nil {
+		t.Fatal("GetDeviceState succeeded despite 500")
+	}
+
+	health := c.BreakerHealth()
+	if health.Failures != 2 || health.ConsecutiveFailures != 2 || health.LastError == "" || health.LastFailure.IsZero() {
+		t.Errorf("health = %+v, want client timeout and 500 counted as failures", health)
+	}
+	if health.Successes != 1 { // /token
+		t.Errorf("Successes = %d, want 1", health.Successes)
+	}
+}
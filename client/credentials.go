@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Переменные окружения по умолчанию для EnvCredentials
+const (
+	DefaultUsernameEnv = "DAICHI_USERNAME"
+	DefaultPasswordEnv = "DAICHI_PASSWORD"
+)
+
+// Credentials — логин и пароль для /token
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// valid — проверяет, что логин и пароль заданы
+func (c Credentials) valid() bool {
+	return c.Username != "" && c.Password != ""
+}
+
+// CredentialsProvider — источник учетных данных.
+// Вызывается при каждом получении токена, поэтому смена пароля подхватывается без перезапуска.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// WithCredentialsProvider — устанавливает источник учетных данных вместо логина и пароля
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(c *DaichiClient) {
+		c.credentialsProvider = provider
+	}
+}
+
+// StaticCredentials — фиксированные логин и пароль
+type StaticCredentials Credentials
+
+// Credentials реализует CredentialsProvider
+func (s StaticCredentials) Credentials(_ context.Context) (Credentials, error) {
+	creds := Credentials(s)
+	if !creds.valid() {
+		return Credentials{}, ErrMissingCredentials
+	}
+	return creds, nil
+}
+
+// EnvCredentials — учетные данные из переменных окружения
+type EnvCredentials struct {
+	UsernameVar string // По умолчанию DAICHI_USERNAME
+	PasswordVar string // По умолчанию DAICHI_PASSWORD
+}
+
+// Credentials реализует CredentialsProvider
+func (e EnvCredentials) Credentials(_ context.Context) (Credentials, error) {
+	usernameVar, passwordVar := e.UsernameVar, e.PasswordVar
+	if usernameVar == "" {
+		usernameVar = DefaultUsernameEnv
+	}
+	if passwordVar == "" {
+		passwordVar = DefaultPasswordEnv
+	}
+
+	creds := Credentials{Username: os.Getenv(usernameVar), Password: os.Getenv(passwordVar)}
+	if !creds.valid() {
+		return Credentials{}, fmt.Errorf("%w: %s/%s are not set", ErrMissingCredentials, usernameVar, passwordVar)
+	}
+	return creds, nil
+}
+
+// FileCredentials — учетные данные из JSON-файла {"username": "...", "password": "..."}
+type FileCredentials struct {
+	Path string
+}
+
+// Credentials реализует CredentialsProvider; файл читается при каждом вызове
+func (f FileCredentials) Credentials(_ context.Context) (Credentials, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return Credentials{}, fmt.Errorf("failed to decode credentials file: %w", err)
+	}
+	if !creds.valid() {
+		return Credentials{}, fmt.Errorf("%w: %s", ErrMissingCredentials, f.Path)
+	}
+	return creds, nil
+}
+
+// ChainCredentials — перебирает источники по порядку до первого успешного
+type ChainCredentials []CredentialsProvider
+
+// Credentials реализует CredentialsProvider
+func (ch ChainCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	errs := make([]error, 0, len(ch))
+	for _, provider := range ch {
+		creds, err := provider.Credentials(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		errs = append(errs, err)
+	}
+	return Credentials{}, fmt.Errorf("%w: %w", ErrMissingCredentials, errors.Join(errs...))
+}
+
+// credentials — возвращает учетные данные из провайдера или заданные опциями
+func (c *DaichiClient) credentials(ctx context.Context) (Credentials, error) {
+	if c.credentialsProvider != nil {
+		return c.credentialsProvider.Credentials(ctx)
+	}
+	return StaticCredentials{Username: c.username, Password: c.password}.Credentials(ctx)
+}
+
+// hasCredentials — проверяет, может ли клиент получить токен самостоятельно
+func (c *DaichiClient) hasCredentials() bool {
+	return c.credentialsProvider != nil || (c.username != "" && c.password != "")
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider EnvCredentials
+		env      map[string]string
+		want     Credentials
+		wantErr  bool
+	}{
+		{
+			"default variables",
+			EnvCredentials{},
+			map[string]string{DefaultUsernameEnv: "user@example.com", DefaultPasswordEnv: "secret"},
+			Credentials{Username: "user@example.com", Password: "secret"},
+			false,
+		},
+		{
+			"custom variables",
+			EnvCredentials{UsernameVar: "APP_LOGIN", PasswordVar: "APP_PASSWORD"},
+			map[string]string{"APP_LOGIN": "user@example.com", "APP_PASSWORD": "secret", DefaultPasswordEnv: "other"},
+			Credentials{Username: "user@example.com", Password: "secret"},
+			false,
+		},
+		{
+			"password not set",
+			EnvCredentials{},
+			map[string]string{DefaultUsernameEnv: "user@example.com"},
+			Credentials{},
+			true,
+		},
+		{"nothing set", EnvCredentials{}, nil, Credentials{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{DefaultUsernameEnv, DefaultPasswordEnv, "APP_LOGIN", "APP_PASSWORD"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			got, err := tt.provider.Credentials(context.Background())
+			if tt.wantErr != errors.Is(err, ErrMissingCredentials) || !tt.wantErr && err != nil {
+				t.Errorf("err = %v, want missing credentials = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("credentials = %+v, want %+v", got, tt.want)
+			}
+			if err != nil && strings.Contains(err.Error(), "secret") {
+				t.Errorf("error contains the password: %v", err)
+			}
+		})
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		want        Credentials
+		wantMissing bool // Ожидается ErrMissingCredentials
+		wantErr     bool
+	}{
+		{
+			"valid",
+			write("valid.json", `{"username":"user@example.com","password":"secret"}`),
+			Credentials{Username: "user@example.com", Password: "secret"},
+			false, false,
+		},
+		{"no password", write("partial.json", `{"username":"user@example.com"}`), Credentials{}, true, true},
+		{"malformed", write("malformed.json", `{"username":`), Credentials{}, false, true},
+		{"missing file", filepath.Join(dir, "missing.json"), Credentials{}, false, true},
+	}
+
+	for _, tt := range tests {
+		got, err := FileCredentials{Path: tt.path}.Credentials(context.Background())
+		if (err != nil) != tt.wantErr || errors.Is(err, ErrMissingCredentials) != tt.wantMissing {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: credentials = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Файл читается при каждом вызове: смена пароля подхватывается сразу
+	path := write("rotated.json", `{"username":"user@example.com","password":"new"}`)
+	if got, err := (FileCredentials{Path: path}).Credentials(context.Background()); err != nil || got.Password != "new" {
+		t.Errorf("rotated credentials = %+v, %v, want password new", got, err)
+	}
+}
+
+// countingCredentials — провайдер, который выдает пароль password-N на N-й вызов
+type countingCredentials struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (p *countingCredentials) Credentials(context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return Credentials{}, p.err
+	}
+	return Credentials{Username: "user@example.com", Password: fmt.Sprintf("password-%d", p.calls)}, nil
+}
+
+func TestGetTokenAsksProviderEachTime(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		passwords []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		passwords = append(passwords, r.FormValue("password"))
+		mu.Unlock()
+		fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+	}))
+	defer srv.Close()
+
+	provider := &countingCredentials{}
+	c := NewDaichiClient(WithBaseURL(srv.URL), WithNoLogs(), WithCredentialsProvider(provider))
+	if !c.hasCredentials() {
+		t.Fatal("client with a provider has no credentials")
+	}
+	// Провайдер не вызывается при создании клиента, только при получении токена
+	if provider.calls != 0 {
+		t.Fatalf("provider calls before GetToken = %d, want 0", provider.calls)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := c.GetToken(ctx); err != nil {
+			t.Fatalf("GetToken: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"password-1", "password-2"}; strings.Join(passwords, ",") != strings.Join(want, ",") {
+		t.Errorf("passwords = %q, want %q", passwords, want)
+	}
+}
+
+func TestGetTokenReportsProviderError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	providerErr := errors.New("vault is sealed")
+	var logs bytes.Buffer
+	c := NewDaichiClient(WithBaseURL(srv.URL), WithLogger(NewLogger(LogError, &logs)),
+		WithCredentialsProvider(&countingCredentials{err: providerErr}))
+
+	if err := c.GetToken(context.Background()); !errors.Is(err, providerErr) {
+		t.Errorf("GetToken err = %v, want the provider error", err)
+	}
+	if out := logs.String(); !strings.Contains(out, "vault is sealed") || strings.Contains(out, "Username and password") {
+		t.Errorf("logs = %q, want the provider error", out)
+	}
+}
@@ -1,27 +1,59 @@
 package client
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
 // MQTTUser — структура для MQTT-данных
 type MQTTUser struct {
 	Username string `json:"username"` // ✅ Экспортируемое поле
 	Password string `json:"password"` // ✅ Экспортируемое поле
 }
 
+// RedactedPassword — пароль MQTT для вывода: вместо значения только его длина
+func (u MQTTUser) RedactedPassword() string {
+	return redactSecret(u.Password)
+}
+
+// String реализует fmt.Stringer и не выводит пароль
+func (u MQTTUser) String() string {
+	return fmt.Sprintf("%s (password %s)", u.Username, u.RedactedPassword())
+}
+
 // DaichiUser — структура данных пользователя
 type DaichiUser struct {
-	ID                       int           `json:"id"`
-	Token                    string        `json:"token"`
-	Email                    string        `json:"email"`
-	MQTTUser                 *MQTTUser     `json:"mqttUser"` // ✅ Указатель на структуру
-	IsEmailConfirmed         bool          `json:"isEmailConfirmed"`
-	Phone                    *string       `json:"phone,omitempty"`
-	IsPhoneConfirmed         bool          `json:"isPhoneConfirmed"`
-	FIO                      string        `json:"fio"`
-	Company                  string        `json:"company"`
-	UserType                 string        `json:"userType"`
-	AccessRequests           []interface{} `json:"accessRequests"`
-	ExpiredIn                *string       `json:"expiredIn,omitempty"`
-	DeleteAccountRequestedAt *string       `json:"deleteAccountRequestedAt,omitempty"`
-	Image                    *string       `json:"image,omitempty"`
+	ID                       int             `json:"id"`
+	Token                    string          `json:"token"`
+	Email                    string          `json:"email"`
+	MQTTUser                 *MQTTUser       `json:"mqttUser"` // ✅ Указатель на структуру
+	IsEmailConfirmed         bool            `json:"isEmailConfirmed"`
+	Phone                    *string         `json:"phone,omitempty"`
+	IsPhoneConfirmed         bool            `json:"isPhoneConfirmed"`
+	FIO                      string          `json:"fio"`
+	Company                  string          `json:"company"`
+	UserType                 UserType        `json:"userType"`
+	AccessRequests           []AccessRequest `json:"accessRequests"`
+	ExpiredIn                NullTime        `json:"expiredIn"`
+	DeleteAccountRequestedAt NullTime        `json:"deleteAccountRequestedAt"`
+	Image                    *string         `json:"image,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Поля ответа, которых нет в модели
+}
+
+// AccessRequest — запрос на доступ к зданию
+type AccessRequest struct {
+	ID         int      `json:"id"`
+	BuildingID int      `json:"buildingId"`
+	Email      string   `json:"email"`
+	FIO        string   `json:"fio,omitempty"`
+	Access     Access   `json:"access"`
+	Status     string   `json:"status"`
+	CreatedAt  NullTime `json:"createdAt"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
 }
 
 // DeviceState — улучшенная структура для поля state
@@ -63,11 +95,79 @@ type DeviceTheme struct {
 	Background string   `json:"background"` // Цвет фона
 }
 
+// Progress — ход длительной операции на устройстве (например, обновления прошивки)
+type Progress struct {
+	Percent int    `json:"percent"`
+	Status  string `json:"status,omitempty"`
+	Text    string `json:"text,omitempty"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
+}
+
+// DevicePreset — сохраненный набор настроек (сценарий) устройства
+type DevicePreset struct {
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Icon     *string `json:"icon,omitempty"`
+	IconName string  `json:"iconName,omitempty"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
+}
+
+// DeviceTimer — серверный таймер включения или выключения
+type DeviceTimer struct {
+	ID      int      `json:"id,omitempty"`
+	IsOn    bool     `json:"isOn"`              // Состояние питания после срабатывания
+	Minutes int      `json:"minutes,omitempty"` // Через сколько минут сработает таймер
+	FireAt  NullTime `json:"fireAt"`            // Время срабатывания
+	Text    string   `json:"text,omitempty"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
+}
+
+// DeviceSubscription — платная подписка устройства
+type DeviceSubscription struct {
+	ID        int      `json:"id"`
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	IsActive  bool     `json:"isActive"`
+	ExpiresAt NullTime `json:"expiresAt"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
+}
+
+// FlexID — идентификатор, который API может вернуть числом или строкой
+type FlexID string
+
+// Int — идентификатор как число; false, если он не числовой
+func (id FlexID) Int() (int, bool) {
+	n, err := strconv.Atoi(string(id))
+	return n, err == nil
+}
+
+// UnmarshalJSON принимает строку, число и любое другое значение (сохраняется как текст)
+func (id *FlexID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		s = string(bytes.TrimSpace(data))
+	}
+	*id = FlexID(s)
+	return nil
+}
+
+// MarshalJSON — число для числовых идентификаторов, иначе строка
+func (id FlexID) MarshalJSON() ([]byte, error) {
+	if n, ok := id.Int(); ok {
+		return json.Marshal(n)
+	}
+	return json.Marshal(string(id))
+}
+
 // DaichiBuildingDeviceStruct — структура устройства в здании
 type DaichiBuildingDeviceStruct struct {
 	ID           int            `json:"id"`
 	Serial       string         `json:"serial"`
-	Status       string         `json:"status"`
+	Status       DeviceStatus   `json:"status"`
 	Title        string         `json:"title"`
 	CurTemp      float64        `json:"curTemp"`
 	State        DeviceState    `json:"state"`
@@ -83,32 +183,34 @@ type DaichiBuildingDeviceStruct struct {
 		IconNames []string `json:"iconNames"`
 	} `json:"currentStateDetailed"`
 
-	GroupID           *interface{} `json:"groupId,omitempty"`
-	BuildingID        int          `json:"buildingId"`
-	LastOnline        string       `json:"lastOnline"`
-	CreatedAt         string       `json:"createdAt"`
-	Pinned            bool         `json:"pinned"`
-	Access            string       `json:"access"`
-	Progress          *interface{} `json:"progress,omitempty"`
-	CurrentPreset     *interface{} `json:"currentPreset,omitempty"`
-	Timer             *interface{} `json:"timer,omitempty"`
-	CloudType         string       `json:"cloudType"`
-	DistributionType  string       `json:"distributionType"`
-	Company           string       `json:"company"`
-	IsBle             bool         `json:"isBle"`
-	DeviceControlType string       `json:"deviceControlType"`
-	FirmwareType      string       `json:"firmwareType"`
-	VrfTitle          *interface{} `json:"vrfTitle,omitempty"`
-	DeviceType        string       `json:"deviceType"`
-	Subscription      *interface{} `json:"subscription,omitempty"`
-	SubscriptionID    *int         `json:"subscriptionId,omitempty"`
-	WarrantyNumber    *string      `json:"warrantyNumber,omitempty"`
-	ConditionerSerial *string      `json:"conditionerSerial,omitempty"`
-	UpdatedAt         *string      `json:"updatedAt,omitempty"`
-	Online            bool         `json:"online,omitempty"`
+	GroupID           *FlexID             `json:"groupId,omitempty"`
+	BuildingID        int                 `json:"buildingId"`
+	LastOnline        NullTime            `json:"lastOnline"`
+	CreatedAt         NullTime            `json:"createdAt"`
+	Pinned            bool                `json:"pinned"`
+	Access            Access              `json:"access"`
+	Progress          *Progress           `json:"progress,omitempty"`
+	CurrentPreset     *DevicePreset       `json:"currentPreset,omitempty"`
+	Timer             *DeviceTimer        `json:"timer,omitempty"`
+	CloudType         CloudType           `json:"cloudType"`
+	DistributionType  DistributionType    `json:"distributionType"`
+	Company           string              `json:"company"`
+	IsBle             bool                `json:"isBle"`
+	DeviceControlType DeviceControlType   `json:"deviceControlType"`
+	FirmwareType      FirmwareType        `json:"firmwareType"`
+	VrfTitle          *string             `json:"vrfTitle,omitempty"`
+	DeviceType        DeviceType          `json:"deviceType"`
+	Subscription      *DeviceSubscription `json:"subscription,omitempty"`
+	SubscriptionID    *int                `json:"subscriptionId,omitempty"`
+	WarrantyNumber    *string             `json:"warrantyNumber,omitempty"`
+	ConditionerSerial *string             `json:"conditionerSerial,omitempty"`
+	UpdatedAt         NullTime            `json:"updatedAt"`
+	Online            bool                `json:"online,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Поля ответа, которых нет в модели
 }
 
 // IsOnline — проверяет, подключен ли кондиционер
 func (d *DaichiBuildingDeviceStruct) IsOnline() bool {
-	return d.Status == "connected"
+	return oneOf(d.Status, StatusConnected)
 }
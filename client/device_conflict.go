@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ConflictResolveVariant — вариант разрешения конфликта, предложенный сервером
+type ConflictResolveVariant struct {
+	Title string `json:"title"`
+	Data  string `json:"data"` // Значение для DeviceControlRequest.ConflictResolveData
+}
+
+// ControlConflict — описание конфликта команды (например, с пресетом или таймером)
+type ControlConflict struct {
+	Title    string                   `json:"title"`
+	Message  string                   `json:"message"`
+	Variants []ConflictResolveVariant `json:"variants"`
+}
+
+// ControlConflictError — сервер отклонил команду как конфликтующую
+type ControlConflictError struct {
+	DeviceID int
+	Request  DeviceControlRequest
+	Conflict ControlConflict
+	Raw      json.RawMessage // Исходный ответ сервера
+}
+
+// Error реализует интерфейс error
+func (e *ControlConflictError) Error() string {
+	if e.Conflict.Message != "" {
+		return fmt.Sprintf("control command for device %d conflicts: %s", e.DeviceID, e.Conflict.Message)
+	}
+	return fmt.Sprintf("control command for device %d conflicts", e.DeviceID)
+}
+
+// Unwrap позволяет сравнивать через errors.Is(err, ErrControlConflict)
+func (e *ControlConflictError) Unwrap() error {
+	return ErrControlConflict
+}
+
+// decodeControlConflict — извлекает описание конфликта из ответа на команду
+func decodeControlConflict(statusCode int, body []byte) *ControlConflict {
+	var response struct {
+		Done   bool            `json:"done"`
+		Errors json.RawMessage `json:"errors"`
+		Data   struct {
+			Conflict *ControlConflict `json:"conflict"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		if statusCode == http.StatusConflict {
+			return &ControlConflict{Message: redactText(string(body))}
+		}
+		return nil
+	}
+
+	if response.Data.Conflict != nil {
+		return response.Data.Conflict
+	}
+
+	if statusCode != http.StatusConflict {
+		return nil
+	}
+
+	var conflict ControlConflict
+	if err := json.Unmarshal(response.Errors, &conflict); err != nil || (conflict.Message == "" && len(conflict.Variants) == 0) {
+		conflict = ControlConflict{Message: string(response.Errors)}
+	}
+	return &conflict
+}
+
+// ResolveConflict — повторно отправляет команду с выбранным вариантом разрешения конфликта
+func (c *DaichiClient) ResolveConflict(ctx context.Context, err error, choice ConflictResolveVariant) (*DaichiBuildingDeviceStruct, error) {
+	var conflictErr *ControlConflictError
+	if !errors.As(err, &conflictErr) {
+		return nil, fmt.Errorf("not a control conflict: %w", err)
+	}
+
+	// Повтор — новая команда: старый cmdId уже использован отклоненной командой
+	control := conflictErr.Request
+	control.CmdID = nextCmdID()
+	data := choice.Data
+	control.ConflictResolveData = &data
+
+	c.Logger.Info("Resolving conflict for device %d: %s", conflictErr.DeviceID, choice.Title)
+	return c.ControlDevice(ctx, conflictErr.DeviceID, control)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDecodeControlConflict(t *testing.T) {
+	variants := []ConflictResolveVariant{
+		{Title: "Override", Data: "override"},
+		{Title: "Keep preset", Data: "keep"},
+	}
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   *ControlConflict
+	}{
+		{
+			"conflict in data",
+			http.StatusOK,
+			`{"done":true,"data":{"conflict":{"title":"Preset","message":"preset is active","variants":[{"title":"Override","data":"override"},{"title":"Keep preset","data":"keep"}]}}}`,
+			&ControlConflict{Title: "Preset", Message: "preset is active", Variants: variants},
+		},
+		{
+			"conflict object in errors",
+			http.StatusConflict,
+			`{"done":false,"errors":{"message":"timer is set","variants":[{"title":"Override","data":"override"},{"title":"Keep preset","data":"keep"}]}}`,
+			&ControlConflict{Message: "timer is set", Variants: variants},
+		},
+		{
+			"string errors",
+			http.StatusConflict,
+			`{"done":false,"errors":"device is busy"}`,
+			&ControlConflict{Message: `"device is busy"`},
+		},
+		{
+			"malformed payload",
+			http.StatusConflict,
+			`conflict: device is busy`,
+			&ControlConflict{Message: "conflict: device is busy"},
+		},
+		{
+			"malformed payload with secret",
+			http.StatusConflict,
+			`conflict for access_token abc`,
+			&ControlConflict{Message: "<non-JSON response omitted>"},
+		},
+		{"no conflict", http.StatusOK, `{"done":true,"data":{"id":1}}`, nil},
+		{"malformed success", http.StatusOK, `not json`, nil},
+		{"other error", http.StatusBadRequest, `{"done":false,"errors":"bad request"}`, nil},
+	}
+
+	for _, tt := range tests {
+		got := decodeControlConflict(tt.status, []byte(tt.body))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: conflict = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveConflictSendsChosenVariant(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		controls []DeviceControlRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+			return
+		}
+		var control DeviceControlRequest
+		if err := json.NewDecoder(r.Body).Decode(&control); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		controls = append(controls, control)
+		mu.Unlock()
+
+		if control.ConflictResolveData == nil {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"done":false,"errors":{"message":"preset is active","variants":[{"title":"Override","data":"override"},{"title":"Keep preset","data":"keep"}]}}`)
+			return
+		}
+		fmt.Fprint(w, `{"done":true,"data":{"id":1,"state":{"isOn":true}}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	on := true
+	_, err = c.ControlDevice(ctx, 1, DeviceControlRequest{Value: DeviceFunctionControl{FunctionID: FunctionPower, IsOn: &on}})
+	var conflict *ControlConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrControlConflict) {
+		t.Fatalf("ControlDevice err = %v, want *ControlConflictError", err)
+	}
+	if _, err := c.ResolveConflict(ctx, err, conflict.Conflict.Variants[1]); err != nil {
+		t.Fatalf("ResolveConflict: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(controls) != 2 {
+		t.Fatalf("controls = %+v, want 2", controls)
+	}
+	// Повтор отправляет ту же команду с данными выбранного варианта
+	resolved := controls[1]
+	if resolved.ConflictResolveData == nil || *resolved.ConflictResolveData != "keep" {
+		t.Errorf("conflictResolveData = %v, want keep", resolved.ConflictResolveData)
+	}
+	if !reflect.DeepEqual(resolved.Value, controls[0].Value) {
+		t.Errorf("resolved value = %+v, want %+v", resolved.Value, controls[0].Value)
+	}
+}
+
+func TestResolveConflictRejectsOtherErrors(t *testing.T) {
+	c := NewDaichiClient(WithNoLogs())
+	if _, err := c.ResolveConflict(context.Background(), ErrInvalidValue, ConflictResolveVariant{Data: "override"}); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("ResolveConflict err = %v, want wrapped ErrInvalidValue", err)
+	}
+}
@@ -1,5 +1,18 @@
 package client
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+)
+
 // DeviceFunctionControl — структура управления функцией устройства
 type DeviceFunctionControl struct {
 	FunctionID int      `json:"functionId"`
@@ -14,3 +27,173 @@ type DeviceControlRequest struct {
 	Value               DeviceFunctionControl `json:"value"`
 	ConflictResolveData *string               `json:"conflictResolveData,omitempty"`
 }
+
+// Transport — способ доставки команд управления
+type Transport int
+
+const (
+	TransportHTTP Transport = iota // Только HTTP API (по умолчанию)
+	TransportMQTT                  // Только MQTT
+	TransportAuto                  // MQTT, если доступен, иначе HTTP (только если команда не была отправлена)
+)
+
+// CommandPublisher — альтернативный транспорт команд (реализуется mqtt.Subscriber)
+type CommandPublisher interface {
+	PublishCommand(ctx context.Context, deviceID int, control DeviceControlRequest) (*DaichiBuildingDeviceStruct, error)
+}
+
+// SetCommandPublisher — подключает транспорт команд после создания клиента
+func (c *DaichiClient) SetCommandPublisher(publisher CommandPublisher) {
+	c.publisherMutex.Lock()
+	defer c.publisherMutex.Unlock()
+	c.publisher = publisher
+}
+
+// buildDeviceControlRequest — создает POST-запрос для управления устройством
+func buildDeviceControlRequest(ctx context.Context, c *DaichiClient, deviceID int, control DeviceControlRequest) (*http.Request, error) {
+	reqURL, err := c.endpointURL(c.endpoints.DeviceControl, deviceID)
+	if err != nil {
+		c.Logger.Error("Failed to build device control URL: %v", err)
+		return nil, fmt.Errorf("invalid device control URL: %w", err)
+	}
+
+	payload, err := json.Marshal(control)
+	if err != nil {
+		c.Logger.Error("Failed to encode device control request: %v", err)
+		return nil, fmt.Errorf("failed to encode device control request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(payload))
+	if err != nil {
+		c.Logger.Error("Failed to create device control request: %v", err)
+		return nil, fmt.Errorf("failed to create device control request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	c.Logger.Debug("Device control request URL: %s, body: %s", reqURL, redactJSON(payload))
+
+	return req, nil
+}
+
+// cmdIDCounter — последний выданный cmdId; начинается со случайного значения,
+// чтобы ID не совпадали между процессами и перезапусками
+var cmdIDCounter atomic.Uint32
+
+func init() {
+	cmdIDCounter.Store(rand.Uint32())
+}
+
+// nextCmdID — уникальный в пределах процесса идентификатор команды от 1 до MaxInt32
+func nextCmdID() int {
+	return int(cmdIDCounter.Add(1)%math.MaxInt32) + 1
+}
+
+// ControlDevice — отправляет команду управления устройству и возвращает обновленное состояние
+func (c *DaichiClient) ControlDevice(ctx context.Context, deviceID int, control DeviceControlRequest) (*DaichiBuildingDeviceStruct, error) {
+	// cmdId — идентификатор команды, генерируем если не задан
+	if control.CmdID == 0 {
+		control.CmdID = nextCmdID()
+	}
+
+	state, err := c.deliverControl(ctx, deviceID, control)
+	c.localizeDevice(state)
+	return state, err
+}
+
+// deliverControl — отправляет команду выбранным транспортом
+func (c *DaichiClient) deliverControl(ctx context.Context, deviceID int, control DeviceControlRequest) (*DaichiBuildingDeviceStruct, error) {
+	c.publisherMutex.RLock()
+	publisher := c.publisher
+	c.publisherMutex.RUnlock()
+
+	switch c.transport {
+	case TransportMQTT:
+		if publisher == nil {
+			c.Logger.Error("MQTT transport selected but no command publisher is set")
+			return nil, ErrTransportUnavailable
+		}
+		return publisher.PublishCommand(ctx, deviceID, control)
+
+	case TransportAuto:
+		if publisher != nil {
+			state, err := publisher.PublishCommand(ctx, deviceID, control)
+			if !errors.Is(err, ErrTransportUnavailable) {
+				return state, err
+			}
+			c.Logger.Warn("MQTT transport unavailable, falling back to HTTP: %v", err)
+		}
+	}
+
+	return c.controlDeviceHTTP(ctx, deviceID, control)
+}
+
+// controlDeviceHTTP — отправляет команду через HTTP API
+func (c *DaichiClient) controlDeviceHTTP(ctx context.Context, deviceID int, control DeviceControlRequest) (*DaichiBuildingDeviceStruct, error) {
+	req, err := buildDeviceControlRequest(ctx, c, deviceID, control)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		c.Logger.Error("API unreachable: %v", err)
+		return nil, fmt.Errorf("API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.Logger.Error("API endpoint not found (404): %s", req.URL.String())
+		return nil, apiErrorFromResponse(resp)
+	}
+
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		c.Logger.Error("Method Not Allowed (405): %s", req.URL.String())
+		return nil, apiErrorFromResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.Logger.Error("Failed to read device control response: %v", err)
+		return nil, fmt.Errorf("failed to read device control response: %w", err)
+	}
+
+	device, err := ParseControlResponse(c.Logger, deviceID, control, resp.StatusCode, body)
+	if err != nil {
+		return nil, err
+	}
+	c.reportDeviceDrift(device)
+	return device, nil
+}
+
+// ParseControlResponse — разбирает ответ на команду управления.
+// Используется HTTP- и MQTT-транспортами, чтобы результаты и ошибки совпадали.
+func ParseControlResponse(logger *Logger, deviceID int, control DeviceControlRequest, statusCode int, body []byte) (*DaichiBuildingDeviceStruct, error) {
+	logger.Debug("Device control response raw: \n%s", formatJSON(redactJSON(body)))
+
+	if conflict := decodeControlConflict(statusCode, body); conflict != nil {
+		logger.Warn("Device %d rejected command as conflicting: %s", deviceID, conflict.Message)
+		return nil, &ControlConflictError{DeviceID: deviceID, Request: control, Conflict: *conflict, Raw: body}
+	}
+
+	if statusCode != http.StatusOK {
+		logger.Error("Non-200 status code: %d, response: %s", statusCode, redactJSON(body))
+		return nil, newAPIError(statusCode, nil, body)
+	}
+
+	var response APIResponse[DaichiBuildingDeviceStruct]
+	if err := json.Unmarshal(body, &response); err != nil {
+		logger.Error("Failed to decode device control response: %v", err)
+		return nil, fmt.Errorf("unmarshal failed: %w", err)
+	}
+
+	if !response.Done {
+		apiErr := newAPIError(statusCode, nil, body)
+		logger.Error("Server returned errors: %v", apiErr)
+		return nil, apiErr
+	}
+
+	logger.WarnUnknownEnums(response.Data.EnumValues()...)
+	logger.Info("Device control applied: \n%s", formatDeviceState(response.Data))
+	return &response.Data, nil
+}
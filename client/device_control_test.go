@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNextCmdIDIsUnique(t *testing.T) {
+	const workers, perWorker = 8, 1000
+	ids := make(chan int, workers*perWorker)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perWorker; j++ {
+				ids <- nextCmdID()
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[int]bool, workers*perWorker)
+	for id := range ids {
+		if id <= 0 {
+			t.Fatalf("cmdId %d is not positive", id)
+		}
+		if seen[id] {
+			t.Fatalf("cmdId %d issued twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestResolveConflictUsesFreshCmdID(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		cmdIDs []int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+			return
+		}
+		var control DeviceControlRequest
+		if err := json.NewDecoder(r.Body).Decode(&control); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		cmdIDs = append(cmdIDs, control.CmdID)
+		mu.Unlock()
+
+		if control.ConflictResolveData == nil {
+			fmt.Fprint(w, `{"done":true,"data":{"conflict":{"message":"preset is active","variants":[{"title":"Override","data":"override"}]}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"done":true,"data":{"id":1,"state":{"isOn":true}}}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	on := true
+	_, err = c.ControlDevice(ctx, 1, DeviceControlRequest{Value: DeviceFunctionControl{FunctionID: 350, IsOn: &on}})
+	var conflict *ControlConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("ControlDevice err = %v, want *ControlConflictError", err)
+	}
+	if _, err := c.ResolveConflict(ctx, err, conflict.Conflict.Variants[0]); err != nil {
+		t.Fatalf("ResolveConflict: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cmdIDs) != 2 || cmdIDs[0] == 0 || cmdIDs[0] == cmdIDs[1] {
+		t.Errorf("cmdIds = %v, want two distinct non-zero IDs", cmdIDs)
+	}
+}
@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+)
+
+// FunctionKind — тип значения функции устройства
+type FunctionKind string
+
+const (
+	FunctionKindBool   FunctionKind = "bool"
+	FunctionKindNumber FunctionKind = "number"
+	FunctionKindEnum   FunctionKind = "enum"
+)
+
+// Названия функций, которые используют типизированные сеттеры
+const (
+	FunctionNamePower       = "power"
+	FunctionNameTemperature = "temperature"
+	FunctionNameMode        = "mode"
+	FunctionNameFanSpeed    = "fanSpeed"
+	FunctionNameSwing       = "swing"
+)
+
+// DeviceFunctionValue — допустимое значение enum-функции
+type DeviceFunctionValue struct {
+	Value float64 `json:"value"`
+	Name  string  `json:"name"`
+	Title string  `json:"title"`
+}
+
+// DeviceFunction — описание функции устройства.
+// Схема ответа /devices/{id}/functions не подтверждена документацией API.
+type DeviceFunction struct {
+	ID     int                   `json:"id"`
+	Name   string                `json:"name"`
+	Title  string                `json:"title"`
+	Kind   FunctionKind          `json:"kind"`
+	Min    *float64              `json:"min,omitempty"`
+	Max    *float64              `json:"max,omitempty"`
+	Step   *float64              `json:"step,omitempty"`
+	Values []DeviceFunctionValue `json:"values,omitempty"`
+}
+
+// DeviceFunctionCatalog — набор функций, поддерживаемых устройством
+type DeviceFunctionCatalog struct {
+	DeviceID  int              `json:"deviceId"`
+	Functions []DeviceFunction `json:"functions"`
+}
+
+// Find — ищет функцию по названию
+func (c *DeviceFunctionCatalog) Find(name string) (*DeviceFunction, bool) {
+	for i := range c.Functions {
+		if c.Functions[i].Name == name {
+			return &c.Functions[i], true
+		}
+	}
+	return nil, false
+}
+
+// ByID — ищет функцию по идентификатору
+func (c *DeviceFunctionCatalog) ByID(id int) (*DeviceFunction, bool) {
+	for i := range c.Functions {
+		if c.Functions[i].ID == id {
+			return &c.Functions[i], true
+		}
+	}
+	return nil, false
+}
+
+// EnumValue — возвращает значение enum-функции по названию
+func (f *DeviceFunction) EnumValue(name string) (float64, bool) {
+	for _, v := range f.Values {
+		if v.Name == name {
+			return v.Value, true
+		}
+	}
+	return 0, false
+}
+
+// Validate — проверяет числовое значение по диапазону и шагу функции
+func (f *DeviceFunction) Validate(value float64) error {
+	switch f.Kind {
+	case FunctionKindEnum:
+		for _, v := range f.Values {
+			if v.Value == value {
+				return nil
+			}
+		}
+		return &ValidationError{Field: f.Name, Value: value, Reason: "not an allowed value"}
+	case FunctionKindNumber:
+		if f.Min != nil && value < *f.Min {
+			return &ValidationError{Field: f.Name, Value: value, Reason: fmt.Sprintf("must be at least %v", *f.Min)}
+		}
+		if f.Max != nil && value > *f.Max {
+			return &ValidationError{Field: f.Name, Value: value, Reason: fmt.Sprintf("must be at most %v", *f.Max)}
+		}
+		if f.Step != nil && *f.Step > 0 {
+			base := 0.0
+			if f.Min != nil {
+				base = *f.Min
+			}
+			if steps := (value - base) / *f.Step; math.Abs(steps-math.Round(steps)) > 1e-9 {
+				return &ValidationError{Field: f.Name, Value: value, Reason: fmt.Sprintf("must be a multiple of %v", *f.Step)}
+			}
+		}
+		return nil
+	default:
+		return &ValidationError{Field: f.Name, Value: value, Reason: "function does not accept numeric values"}
+	}
+}
+
+// DefaultFunctionCatalog — каталог для WithFallbackFunctionCatalog, если сервер не поддерживает описание функций.
+// ID 350–354 и диапазоны значений не подтверждены документацией API (см. FunctionPower);
+// перед использованием на новых моделях сверьте их с GetDeviceFunctions или трафиком приложения.
+func DefaultFunctionCatalog(deviceID int) *DeviceFunctionCatalog {
+	ptr := func(v float64) *float64 { return &v }
+
+	modes := make([]DeviceFunctionValue, 0, int(ModeAuto))
+	for m := ModeCool; m <= ModeAuto; m++ {
+		modes = append(modes, DeviceFunctionValue{Value: float64(m), Name: m.String()})
+	}
+	swings := make([]DeviceFunctionValue, 0, int(SwingBoth)+1)
+	for s := SwingOff; s <= SwingBoth; s++ {
+		swings = append(swings, DeviceFunctionValue{Value: float64(s), Name: s.String()})
+	}
+
+	return &DeviceFunctionCatalog{
+		DeviceID: deviceID,
+		Functions: []DeviceFunction{
+			{ID: FunctionPower, Name: FunctionNamePower, Kind: FunctionKindBool},
+			{
+				ID:   FunctionTemperature,
+				Name: FunctionNameTemperature,
+				Kind: FunctionKindNumber,
+				Min:  ptr(MinTargetTemperature),
+				Max:  ptr(MaxTargetTemperature),
+				Step: ptr(TargetTemperatureStep),
+			},
+			{ID: FunctionMode, Name: FunctionNameMode, Kind: FunctionKindEnum, Values: modes},
+			{
+				ID:   FunctionFanSpeed,
+				Name: FunctionNameFanSpeed,
+				Kind: FunctionKindNumber,
+				Min:  ptr(MinFanSpeed),
+				Max:  ptr(MaxFanSpeed),
+				Step: ptr(1),
+			},
+			{ID: FunctionSwing, Name: FunctionNameSwing, Kind: FunctionKindEnum, Values: swings},
+		},
+	}
+}
+
+// WithFallbackFunctionCatalog — каталог, который используется, если сервер не поддерживает /functions.
+// По умолчанию фолбэка нет, и сеттеры возвращают ошибку сервера: ID из DefaultFunctionCatalog
+// не подтверждены, поэтому их отправка на устройство включается только явно.
+func WithFallbackFunctionCatalog(fallback func(deviceID int) *DeviceFunctionCatalog) Option {
+	return func(c *DaichiClient) {
+		c.fallbackCatalog = fallback
+	}
+}
+
+// buildDeviceFunctionsRequest — создает GET-запрос для получения функций устройства
+func buildDeviceFunctionsRequest(ctx context.Context, c *DaichiClient, deviceID int) (*http.Request, error) {
+	reqURL, err := c.endpointURL(c.endpoints.DeviceFunctions, deviceID)
+	if err != nil {
+		c.Logger.Error("Failed to build device functions URL: %v", err)
+		return nil, fmt.Errorf("invalid device functions URL: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		c.Logger.Error("Failed to create device functions request: %v", err)
+		return nil, fmt.Errorf("failed to create device functions request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	c.Logger.Debug("Device functions request URL: %s", reqURL)
+
+	return req, nil
+}
+
+// GetDeviceFunctions — возвращает каталог функций устройства
+func (c *DaichiClient) GetDeviceFunctions(ctx context.Context, deviceID int) (*DeviceFunctionCatalog, error) {
+	req, err := buildDeviceFunctionsRequest(ctx, c, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		c.Logger.Error("API unreachable: %v", err)
+		return nil, fmt.Errorf("API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.Logger.Error("API endpoint not found (404): %s", req.URL.String())
+		return nil, apiErrorFromResponse(resp)
+	}
+
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		c.Logger.Error("Method Not Allowed (405): %s", req.URL.String())
+		return nil, apiErrorFromResponse(resp)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		c.Logger.Error("Non-200 status code: %d, response: %s", resp.StatusCode, redactJSON(body))
+		return nil, newAPIError(resp.StatusCode, resp.Header, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.Logger.Error("Failed to read device functions response: %v", err)
+		return nil, fmt.Errorf("failed to read device functions response: %w", err)
+	}
+
+	var response APIResponse[[]DeviceFunction]
+	if err := json.Unmarshal(body, &response); err != nil {
+		c.Logger.Error("Failed to decode device functions: %v", err)
+		return nil, fmt.Errorf("unmarshal failed: %w", err)
+	}
+
+	if !response.Done {
+		apiErr := newAPIError(resp.StatusCode, resp.Header, body)
+		c.Logger.Error("Server returned errors: %v", apiErr)
+		return nil, apiErr
+	}
+
+	catalog := &DeviceFunctionCatalog{DeviceID: deviceID, Functions: response.Data}
+
+	c.functionsMutex.Lock()
+	c.functions[deviceID] = catalog
+	c.functionsMutex.Unlock()
+
+	c.Logger.Info("Device functions received: %d", len(catalog.Functions))
+	return catalog, nil
+}
+
+// deviceFunctions — возвращает каталог из кэша, запрашивая его при необходимости.
+// Если сервер не поддерживает /functions, это запоминается для устройства,
+// а каталог из WithFallbackFunctionCatalog кэшируется как полученный с сервера.
+func (c *DaichiClient) deviceFunctions(ctx context.Context, deviceID int) (*DeviceFunctionCatalog, error) {
+	c.functionsMutex.RLock()
+	catalog, ok := c.functions[deviceID]
+	unsupportedErr := c.functionsUnsupported[deviceID]
+	c.functionsMutex.RUnlock()
+	if ok {
+		return catalog, nil
+	}
+
+	if unsupportedErr == nil {
+		catalog, err := c.GetDeviceFunctions(ctx, deviceID)
+		if err == nil || !c.catalogUnsupported(ctx, deviceID, err) {
+			return catalog, err
+		}
+		unsupportedErr = err
+
+		c.functionsMutex.Lock()
+		c.functionsUnsupported[deviceID] = err
+		c.functionsMutex.Unlock()
+	}
+
+	if c.fallbackCatalog == nil {
+		return nil, unsupportedErr
+	}
+
+	c.Logger.Warn("Function catalog is not supported for device %d, using fallback catalog", deviceID)
+	catalog = c.fallbackCatalog(deviceID)
+
+	c.functionsMutex.Lock()
+	c.functions[deviceID] = catalog
+	c.functionsMutex.Unlock()
+	return catalog, nil
+}
+
+// catalogUnsupported — сервер не поддерживает /functions, а не отклонил запрос к устройству.
+// 404 неоднозначен (нет маршрута или нет устройства), поэтому существование устройства проверяется отдельно.
+func (c *DaichiClient) catalogUnsupported(ctx context.Context, deviceID int, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusNotFound:
+		if _, stateErr := c.GetDeviceState(ctx, deviceID); stateErr != nil {
+			c.Logger.Warn("Device %d not found, not using default function catalog: %v", deviceID, stateErr)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// lookupFunction — ищет функцию устройства по названию
+func (c *DaichiClient) lookupFunction(ctx context.Context, deviceID int, name string) (*DeviceFunction, error) {
+	catalog, err := c.deviceFunctions(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, ok := catalog.Find(name)
+	if !ok {
+		return nil, &ValidationError{Field: name, Reason: fmt.Sprintf("not supported by device %d", deviceID)}
+	}
+	return fn, nil
+}
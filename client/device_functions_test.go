@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// catalogAPI — API без /functions, в котором есть только устройство 1
+type catalogAPI struct {
+	*httptest.Server
+
+	mu              sync.Mutex
+	functionsStatus int
+	functionsCalls  int
+	stateCalls      int
+	commands        map[string][]DeviceControlRequest // URL → команды
+}
+
+func newCatalogAPI(t *testing.T, functionsStatus int) *catalogAPI {
+	t.Helper()
+	api := &catalogAPI{functionsStatus: functionsStatus, commands: make(map[string][]DeviceControlRequest)}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		switch {
+		case strings.HasSuffix(path, "/token"):
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+		case strings.HasSuffix(path, "/functions"):
+			api.functionsCalls++
+			w.WriteHeader(api.functionsStatus)
+			fmt.Fprint(w, `{"done":false,"errors":"not found"}`)
+		case strings.HasSuffix(path, "/ctrl"):
+			var control DeviceControlRequest
+			_ = json.NewDecoder(r.Body).Decode(&control)
+			api.commands[path] = append(api.commands[path], control)
+			fmt.Fprint(w, `{"done":true,"data":{"id":1}}`)
+		case strings.HasSuffix(path, "/devices/1"):
+			api.stateCalls++
+			fmt.Fprint(w, `{"done":true,"data":{"id":1,"status":"connected"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"done":false,"errors":"device not found"}`)
+		}
+	}))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *catalogAPI) client(t *testing.T, opts ...Option) *AuthorizedDaichiClient {
+	t.Helper()
+	opts = append([]Option{WithBaseURL(api.URL), WithNoLogs()}, opts...)
+	c, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password", opts...)
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestDefaultCatalogOnlyForExistingDevice(t *testing.T) {
+	api := newCatalogAPI(t, http.StatusNotFound)
+	c := api.client(t, WithFallbackFunctionCatalog(DefaultFunctionCatalog))
+	ctx := context.Background()
+
+	if _, err := c.SetPower(ctx, 999, true); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("SetPower(999) err = %v, want ErrEndpointNotFound", err)
+	}
+	if _, err := c.SetPower(ctx, 1, true); err != nil {
+		t.Fatalf("SetPower(1): %v", err)
+	}
+	if _, err := c.SetPower(ctx, 1, false); err != nil {
+		t.Fatalf("SetPower(1): %v", err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if sent := api.commands["/devices/999/ctrl"]; len(sent) != 0 {
+		t.Errorf("sent %d commands to a nonexistent device", len(sent))
+	}
+	sent := api.commands["/devices/1/ctrl"]
+	if len(sent) != 2 || sent[0].Value.FunctionID != FunctionPower {
+		t.Errorf("commands to device 1 = %+v, want two power commands", sent)
+	}
+	// Каталог-фолбэк кэшируется: второй SetPower(1) не запрашивает /functions и состояние
+	if api.functionsCalls != 2 || api.stateCalls != 1 {
+		t.Errorf("/functions calls = %d, state calls = %d, want 2 and 1", api.functionsCalls, api.stateCalls)
+	}
+}
+
+func TestDefaultCatalogForUnsupportedEndpoint(t *testing.T) {
+	api := newCatalogAPI(t, http.StatusNotImplemented)
+	c := api.client(t, WithFallbackFunctionCatalog(DefaultFunctionCatalog))
+
+	if _, err := c.SetPower(context.Background(), 1, true); err != nil {
+		t.Fatalf("SetPower: %v", err)
+	}
+}
+
+func TestDefaultCatalogNotUsedForOtherErrors(t *testing.T) {
+	api := newCatalogAPI(t, http.StatusForbidden)
+	c := api.client(t, WithFallbackFunctionCatalog(DefaultFunctionCatalog))
+
+	if _, err := c.SetPower(context.Background(), 1, true); err == nil {
+		t.Fatal("SetPower succeeded with default catalog after 403")
+	}
+}
+
+func TestFallbackCatalogIsOptIn(t *testing.T) {
+	api := newCatalogAPI(t, http.StatusNotFound)
+	c := api.client(t)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.SetPower(context.Background(), 1, true); !errors.Is(err, ErrEndpointNotFound) {
+			t.Errorf("err = %v, want ErrEndpointNotFound", err)
+		}
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if sent := api.commands["/devices/1/ctrl"]; len(sent) != 0 {
+		t.Errorf("sent %d commands without a function catalog", len(sent))
+	}
+	// Отсутствие /functions запоминается для устройства
+	if api.functionsCalls != 1 || api.stateCalls != 1 {
+		t.Errorf("/functions calls = %d, state calls = %d, want 1 and 1", api.functionsCalls, api.stateCalls)
+	}
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// Идентификаторы функций кондиционера по умолчанию.
+// Документации API с этими значениями нет: это предположение SDK, которое используется
+// только в DefaultFunctionCatalog, который подключается явно через WithFallbackFunctionCatalog. Каталог с сервера (GetDeviceFunctions) всегда приоритетнее.
+const (
+	FunctionPower       = 350
+	FunctionTemperature = 351
+	FunctionMode        = 352
+	FunctionFanSpeed    = 353
+	FunctionSwing       = 354
+)
+
+// Допустимые диапазоны значений по умолчанию
+const (
+	MinTargetTemperature  = 16.0
+	MaxTargetTemperature  = 32.0
+	TargetTemperatureStep = 0.5
+	MinFanSpeed           = 0 // 0 — автоматическая скорость
+	MaxFanSpeed           = 5
+)
+
+// DeviceMode — режим работы кондиционера
+type DeviceMode int
+
+const (
+	ModeCool DeviceMode = iota + 1
+	ModeHeat
+	ModeDry
+	ModeFan
+	ModeAuto
+)
+
+// String — возвращает название режима
+func (m DeviceMode) String() string {
+	switch m {
+	case ModeCool:
+		return "cool"
+	case ModeHeat:
+		return "heat"
+	case ModeDry:
+		return "dry"
+	case ModeFan:
+		return "fan"
+	case ModeAuto:
+		return "auto"
+	default:
+		return fmt.Sprintf("DeviceMode(%d)", int(m))
+	}
+}
+
+// IsValid — проверяет, что режим известен
+func (m DeviceMode) IsValid() bool {
+	return m >= ModeCool && m <= ModeAuto
+}
+
+// SwingMode — режим качания жалюзи
+type SwingMode int
+
+const (
+	SwingOff SwingMode = iota
+	SwingVertical
+	SwingHorizontal
+	SwingBoth
+)
+
+// String — возвращает название режима качания
+func (s SwingMode) String() string {
+	switch s {
+	case SwingOff:
+		return "off"
+	case SwingVertical:
+		return "vertical"
+	case SwingHorizontal:
+		return "horizontal"
+	case SwingBoth:
+		return "both"
+	default:
+		return fmt.Sprintf("SwingMode(%d)", int(s))
+	}
+}
+
+// IsValid — проверяет, что режим качания известен
+func (s SwingMode) IsValid() bool {
+	return s >= SwingOff && s <= SwingBoth
+}
+
+// sendFunction — отправляет команду для одной функции устройства
+func (c *DaichiClient) sendFunction(ctx context.Context, deviceID int, fn DeviceFunctionControl) (*DaichiBuildingDeviceStruct, error) {
+	return c.ControlDevice(ctx, deviceID, DeviceControlRequest{Value: fn})
+}
+
+// numberFunction — формирует команду с числовым значением
+func numberFunction(functionID int, value float64) DeviceFunctionControl {
+	return DeviceFunctionControl{FunctionID: functionID, Value: &value}
+}
+
+// SetPower — включает или выключает кондиционер
+func (c *DaichiClient) SetPower(ctx context.Context, deviceID int, on bool) (*DaichiBuildingDeviceStruct, error) {
+	fn, err := c.lookupFunction(ctx, deviceID, FunctionNamePower)
+	if err != nil {
+		return nil, err
+	}
+	if fn.Kind != FunctionKindBool {
+		return nil, &ValidationError{Field: fn.Name, Value: on, Reason: "function does not accept boolean values"}
+	}
+	return c.sendFunction(ctx, deviceID, DeviceFunctionControl{FunctionID: fn.ID, IsOn: &on})
+}
+
+// SetTargetTemperature — устанавливает целевую температуру
+func (c *DaichiClient) SetTargetTemperature(ctx context.Context, deviceID int, celsius float64) (*DaichiBuildingDeviceStruct, error) {
+	return c.setNumber(ctx, deviceID, FunctionNameTemperature, celsius)
+}
+
+// SetMode — устанавливает режим работы
+func (c *DaichiClient) SetMode(ctx context.Context, deviceID int, mode DeviceMode) (*DaichiBuildingDeviceStruct, error) {
+	if !mode.IsValid() {
+		return nil, &ValidationError{Field: FunctionNameMode, Value: mode, Reason: "unknown mode"}
+	}
+	return c.setEnum(ctx, deviceID, FunctionNameMode, mode.String())
+}
+
+// SetFanSpeed — устанавливает скорость вентилятора (0 — авто)
+func (c *DaichiClient) SetFanSpeed(ctx context.Context, deviceID int, speed int) (*DaichiBuildingDeviceStruct, error) {
+	return c.setNumber(ctx, deviceID, FunctionNameFanSpeed, float64(speed))
+}
+
+// SetSwing — устанавливает режим качания жалюзи
+func (c *DaichiClient) SetSwing(ctx context.Context, deviceID int, swing SwingMode) (*DaichiBuildingDeviceStruct, error) {
+	if !swing.IsValid() {
+		return nil, &ValidationError{Field: FunctionNameSwing, Value: swing, Reason: "unknown swing mode"}
+	}
+	return c.setEnum(ctx, deviceID, FunctionNameSwing, swing.String())
+}
+
+// setNumber — проверяет и отправляет числовое значение функции
+func (c *DaichiClient) setNumber(ctx context.Context, deviceID int, name string, value float64) (*DaichiBuildingDeviceStruct, error) {
+	fn, err := c.lookupFunction(ctx, deviceID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn.Validate(value); err != nil {
+		return nil, err
+	}
+	return c.sendFunction(ctx, deviceID, numberFunction(fn.ID, value))
+}
+
+// setEnum — находит значение enum-функции по названию и отправляет его
+func (c *DaichiClient) setEnum(ctx context.Context, deviceID int, name, valueName string) (*DaichiBuildingDeviceStruct, error) {
+	fn, err := c.lookupFunction(ctx, deviceID, name)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := fn.EnumValue(valueName)
+	if !ok {
+		return nil, &ValidationError{Field: name, Value: valueName, Reason: fmt.Sprintf("not supported by device %d", deviceID)}
+	}
+	return c.sendFunction(ctx, deviceID, numberFunction(fn.ID, value))
+}
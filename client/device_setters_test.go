@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSetters(t *testing.T) {
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		set     func(ctx context.Context, c *AuthorizedDaichiClient) error
+		wantErr bool
+		wantFn  int
+		wantOn  *bool
+		wantVal *float64
+	}{
+		{"power on", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetPower(ctx, 1, true)
+			return err
+		}, false, FunctionPower, func() *bool { v := true; return &v }(), nil},
+		{"power off", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetPower(ctx, 1, false)
+			return err
+		}, false, FunctionPower, func() *bool { v := false; return &v }(), nil},
+
+		{"temperature min", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, MinTargetTemperature)
+			return err
+		}, false, FunctionTemperature, nil, ptr(MinTargetTemperature)},
+		{"temperature half step", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, 22.5)
+			return err
+		}, false, FunctionTemperature, nil, ptr(22.5)},
+		{"temperature max", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, MaxTargetTemperature)
+			return err
+		}, false, FunctionTemperature, nil, ptr(MaxTargetTemperature)},
+		{"temperature below range", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, MinTargetTemperature-1)
+			return err
+		}, true, 0, nil, nil},
+		{"temperature above range", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, MaxTargetTemperature+0.5)
+			return err
+		}, true, 0, nil, nil},
+		{"temperature off step", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetTargetTemperature(ctx, 1, 22.3)
+			return err
+		}, true, 0, nil, nil},
+
+		{"mode cool", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetMode(ctx, 1, ModeCool)
+			return err
+		}, false, FunctionMode, nil, ptr(float64(ModeCool))},
+		{"mode auto", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetMode(ctx, 1, ModeAuto)
+			return err
+		}, false, FunctionMode, nil, ptr(float64(ModeAuto))},
+		{"mode zero", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetMode(ctx, 1, 0)
+			return err
+		}, true, 0, nil, nil},
+		{"mode unknown", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetMode(ctx, 1, ModeAuto+1)
+			return err
+		}, true, 0, nil, nil},
+
+		{"fan auto", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetFanSpeed(ctx, 1, MinFanSpeed)
+			return err
+		}, false, FunctionFanSpeed, nil, ptr(MinFanSpeed)},
+		{"fan max", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetFanSpeed(ctx, 1, MaxFanSpeed)
+			return err
+		}, false, FunctionFanSpeed, nil, ptr(MaxFanSpeed)},
+		{"fan negative", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetFanSpeed(ctx, 1, -1)
+			return err
+		}, true, 0, nil, nil},
+		{"fan above range", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetFanSpeed(ctx, 1, MaxFanSpeed+1)
+			return err
+		}, true, 0, nil, nil},
+
+		{"swing off", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetSwing(ctx, 1, SwingOff)
+			return err
+		}, false, FunctionSwing, nil, ptr(float64(SwingOff))},
+		{"swing both", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetSwing(ctx, 1, SwingBoth)
+			return err
+		}, false, FunctionSwing, nil, ptr(float64(SwingBoth))},
+		{"swing negative", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetSwing(ctx, 1, -1)
+			return err
+		}, true, 0, nil, nil},
+		{"swing unknown", func(ctx context.Context, c *AuthorizedDaichiClient) error {
+			_, err := c.SetSwing(ctx, 1, SwingBoth+1)
+			return err
+		}, true, 0, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newCatalogAPI(t, http.StatusNotFound)
+			c := api.client(t, WithFallbackFunctionCatalog(DefaultFunctionCatalog))
+
+			err := tt.set(context.Background(), c)
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			commands := api.commands["/devices/1/ctrl"]
+			if tt.wantErr {
+				// Недопустимое значение отклоняется до отправки команды
+				if !errors.Is(err, ErrInvalidValue) {
+					t.Errorf("err = %v, want ErrInvalidValue", err)
+				}
+				if len(api.commands) != 0 {
+					t.Errorf("commands = %+v, want none", api.commands)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if len(commands) != 1 {
+				t.Fatalf("commands = %+v, want one for device 1", api.commands)
+			}
+			got := commands[0].Value
+			if got.FunctionID != tt.wantFn {
+				t.Errorf("functionId = %d, want %d", got.FunctionID, tt.wantFn)
+			}
+			if (got.IsOn == nil) != (tt.wantOn == nil) || got.IsOn != nil && *got.IsOn != *tt.wantOn {
+				t.Errorf("isOn = %v, want %v", got.IsOn, tt.wantOn)
+			}
+			if (got.Value == nil) != (tt.wantVal == nil) || got.Value != nil && *got.Value != *tt.wantVal {
+				t.Errorf("value = %v, want %v", got.Value, tt.wantVal)
+			}
+		})
+	}
+}
+
+func TestEnumSetterRejectsValueMissingFromCatalog(t *testing.T) {
+	api := newCatalogAPI(t, http.StatusNotFound)
+	// Каталог устройства без режима качания both
+	catalog := func(deviceID int) *DeviceFunctionCatalog {
+		c := DefaultFunctionCatalog(deviceID)
+		for i, fn := range c.Functions {
+			if fn.Name == FunctionNameSwing {
+				c.Functions[i].Values = fn.Values[:len(fn.Values)-1]
+			}
+		}
+		return c
+	}
+	c := api.client(t, WithFallbackFunctionCatalog(catalog))
+
+	if _, err := c.SetSwing(context.Background(), 1, SwingBoth); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("SetSwing(both) err = %v, want ErrInvalidValue", err)
+	}
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if len(api.commands) != 0 {
+		t.Errorf("commands = %+v, want none", api.commands)
+	}
+}
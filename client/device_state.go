@@ -0,0 +1,190 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OperatingState — рабочее состояние кондиционера, разобранное из иконок и деталей state.
+// Значения, которые не удалось определить, остаются nil (или 0 для Mode).
+type OperatingState struct {
+	IsOn              bool
+	Mode              DeviceMode // 0 — режим не определен
+	TargetTemperature *float64
+	FanSpeed          *int // 0 — автоматическая скорость
+	Swing             *SwingMode
+	Eco               bool
+	Turbo             bool
+	Sleep             bool
+	Unknown           []string // Нераспознанные имена иконок и тексты деталей
+}
+
+// stateTemperatureRe — температура в тексте детали: «24°», «+24.5 °C»
+var stateTemperatureRe = regexp.MustCompile(`([+-]?\d+(?:[.,]\d+)?)\s*°`)
+
+// stateModeIcons — имена иконок режимов
+var stateModeIcons = map[string]DeviceMode{
+	"cool":        ModeCool,
+	"cooling":     ModeCool,
+	"heat":        ModeHeat,
+	"heating":     ModeHeat,
+	"dry":         ModeDry,
+	"dehumidify":  ModeDry,
+	"fan":         ModeFan,
+	"fan_only":    ModeFan,
+	"ventilation": ModeFan,
+	"auto":        ModeAuto,
+}
+
+// stateSwingIcons — имена иконок качания жалюзи
+var stateSwingIcons = map[string]SwingMode{
+	"swing_off":        SwingOff,
+	"swing_vertical":   SwingVertical,
+	"swing_horizontal": SwingHorizontal,
+	"swing_both":       SwingBoth,
+	"swing_3d":         SwingBoth,
+}
+
+// stateTemperatureIcons — имена иконок, текст которых содержит целевую температуру
+var stateTemperatureIcons = map[string]bool{
+	"temperature":        true,
+	"target_temperature": true,
+	"thermometer":        true,
+}
+
+// OperatingState — разбирает состояние устройства из State и CurrentStateDetailed
+func (d *DaichiBuildingDeviceStruct) OperatingState() OperatingState {
+	state := OperatingState{IsOn: d.State.IsOn}
+	seen := make(map[string]bool)
+
+	for _, name := range d.State.Info.IconNames {
+		state.apply(name, "", seen)
+	}
+	for _, group := range d.State.Details {
+		for _, detail := range group.Details {
+			var text string
+			if detail.Text != nil {
+				text = *detail.Text
+			}
+			state.apply(detail.IconName, text, seen)
+		}
+	}
+	for _, detail := range d.CurrentStateDetailed {
+		if len(detail.IconNames) == 0 {
+			state.apply("", detail.Text, seen)
+		}
+		for _, name := range detail.IconNames {
+			state.apply(name, detail.Text, seen)
+		}
+	}
+
+	return state
+}
+
+// apply — учитывает одну иконку и текст детали
+func (s *OperatingState) apply(iconName, text string, seen map[string]bool) {
+	name := normalizeIconName(iconName)
+	text = strings.TrimSpace(text)
+
+	// Текст без иконки («27°») может быть и комнатной, и целевой температурой,
+	// поэтому уставка берется только из иконок stateTemperatureIcons
+	if name != "" && s.applyIcon(name, text) {
+		return
+	}
+
+	value := iconName
+	if value == "" {
+		value = text
+	}
+	if value != "" && !seen[value] {
+		seen[value] = true
+		s.Unknown = append(s.Unknown, value)
+	}
+}
+
+// applyIcon — применяет распознанную иконку; false, если иконка неизвестна
+func (s *OperatingState) applyIcon(name, text string) bool {
+	name = strings.TrimPrefix(name, "mode_")
+
+	if mode, ok := stateModeIcons[name]; ok {
+		s.Mode = mode
+		return true
+	}
+	if swing, ok := stateSwingIcons[name]; ok {
+		s.Swing = &swing
+		return true
+	}
+	if stateTemperatureIcons[name] {
+		celsius, ok := parseStateTemperature(text)
+		if ok {
+			s.TargetTemperature = &celsius
+		}
+		return ok
+	}
+	if speed, ok := parseFanSpeedIcon(name); ok {
+		s.FanSpeed = &speed
+		return true
+	}
+
+	switch name {
+	case "power", "power_on", "power_off":
+		return true
+	case "swing":
+		swing := SwingVertical
+		s.Swing = &swing
+		return true
+	case "eco":
+		s.Eco = true
+		return true
+	case "turbo", "powerful":
+		s.Turbo = true
+		return true
+	case "sleep", "night":
+		s.Sleep = true
+		return true
+	}
+	return false
+}
+
+// normalizeIconName — приводит имя иконки к виду «fan_speed_3»
+func normalizeIconName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.NewReplacer("-", "_", " ", "_").Replace(name)
+	for _, prefix := range []string{"ic_", "icon_"} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name
+}
+
+// parseFanSpeedIcon — разбирает иконки скорости: fan_speed_3, speed_3, fan_auto
+func parseFanSpeedIcon(name string) (int, bool) {
+	for _, prefix := range []string{"fan_speed_", "speed_", "fan_"} {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if rest == "auto" {
+			return MinFanSpeed, true
+		}
+		speed, err := strconv.Atoi(rest)
+		if err != nil || speed < MinFanSpeed || speed > MaxFanSpeed {
+			return 0, false
+		}
+		return speed, true
+	}
+	return 0, false
+}
+
+// parseStateTemperature — извлекает температуру из текста детали
+func parseStateTemperature(text string) (float64, bool) {
+	match := stateTemperatureRe.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	celsius, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return celsius, true
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeDevice(t *testing.T, raw string) DaichiBuildingDeviceStruct {
+	t.Helper()
+	var d DaichiBuildingDeviceStruct
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		t.Fatalf("unmarshal %s: %v", raw, err)
+	}
+	return d
+}
+
+func TestOperatingStateIcons(t *testing.T) {
+	d := decodeDevice(t, `{"state":{"isOn":true,`+
+		`"info":{"iconNames":["ic_mode_cool","fan-speed-3","swing_vertical","eco","turbo","sleep","mystery"]},`+
+		`"details":[{"details":[{"iconName":"temperature","text":"+22,5 °C"}]}]}}`)
+
+	s := d.OperatingState()
+	if !s.IsOn || s.Mode != ModeCool || !s.Eco || !s.Turbo || !s.Sleep {
+		t.Errorf("state = %+v", s)
+	}
+	if s.TargetTemperature == nil || *s.TargetTemperature != 22.5 {
+		t.Errorf("TargetTemperature = %v, want 22.5", s.TargetTemperature)
+	}
+	if s.FanSpeed == nil || *s.FanSpeed != 3 {
+		t.Errorf("FanSpeed = %v, want 3", s.FanSpeed)
+	}
+	if s.Swing == nil || *s.Swing != SwingVertical {
+		t.Errorf("Swing = %v, want vertical", s.Swing)
+	}
+	if !reflect.DeepEqual(s.Unknown, []string{"mystery"}) {
+		t.Errorf("Unknown = %q, want [mystery]", s.Unknown)
+	}
+}
+
+func TestOperatingStateIconMapping(t *testing.T) {
+	tests := []struct {
+		icon  string
+		mode  DeviceMode
+		fan   int // -1 — скорость не задана
+		swing SwingMode
+	}{
+		{"heating", ModeHeat, -1, -1},
+		{"dehumidify", ModeDry, -1, -1},
+		{"fan_only", ModeFan, -1, -1},
+		{"mode_auto", ModeAuto, -1, -1},
+		{"fan_auto", 0, MinFanSpeed, -1},
+		{"speed_5", 0, 5, -1},
+		{"swing_3d", 0, -1, SwingBoth},
+		{"swing", 0, -1, SwingVertical},
+	}
+
+	for _, tt := range tests {
+		d := decodeDevice(t, `{"state":{"info":{"iconNames":["`+tt.icon+`"]}}}`)
+		s := d.OperatingState()
+		if s.Mode != tt.mode {
+			t.Errorf("%s: Mode = %v, want %v", tt.icon, s.Mode, tt.mode)
+		}
+		if tt.fan >= 0 && (s.FanSpeed == nil || *s.FanSpeed != tt.fan) {
+			t.Errorf("%s: FanSpeed = %v, want %d", tt.icon, s.FanSpeed, tt.fan)
+		}
+		if tt.swing >= 0 && (s.Swing == nil || *s.Swing != tt.swing) {
+			t.Errorf("%s: Swing = %v, want %v", tt.icon, s.Swing, tt.swing)
+		}
+		if len(s.Unknown) != 0 {
+			t.Errorf("%s: Unknown = %q", tt.icon, s.Unknown)
+		}
+	}
+}
+
+func TestOperatingStateTextWithoutIconIsNotSetpoint(t *testing.T) {
+	d := decodeDevice(t, `{"state":{"details":[{"details":[{"text":"27°"}]}]},`+
+		`"currentStateDetailed":[{"text":"26 °C"}]}`)
+
+	s := d.OperatingState()
+	if s.TargetTemperature != nil {
+		t.Errorf("TargetTemperature = %v, want nil for text without an icon", *s.TargetTemperature)
+	}
+	if !reflect.DeepEqual(s.Unknown, []string{"27°", "26 °C"}) {
+		t.Errorf("Unknown = %q, want [27° 26 °C]", s.Unknown)
+	}
+}
+
+func TestOperatingStateUnreadableTemperatureIcon(t *testing.T) {
+	d := decodeDevice(t, `{"state":{"details":[{"details":[{"iconName":"thermometer","text":"--"}]}]}}`)
+
+	s := d.OperatingState()
+	if s.TargetTemperature != nil {
+		t.Errorf("TargetTemperature = %v, want nil", *s.TargetTemperature)
+	}
+	if !reflect.DeepEqual(s.Unknown, []string{"thermometer"}) {
+		t.Errorf("Unknown = %q, want [thermometer]", s.Unknown)
+	}
+}
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Параметры ожидания по умолчанию
+const (
+	DefaultWaitPollInterval = 2 * time.Second
+	DefaultWaitTimeout      = 30 * time.Second
+)
+
+// WaitOptions — параметры ожидания применения команды
+type WaitOptions struct {
+	// PollInterval — интервал опроса GetDeviceState
+	PollInterval time.Duration
+	// Timeout — ограничение ожидания, если у контекста нет дедлайна
+	Timeout time.Duration
+	// Match — проверяет, что состояние отражает команду.
+	// Для питания, целевой температуры, режима, скорости и качания проверка строится
+	// автоматически по каталогу функций устройства и OperatingState.
+	Match func(*DaichiBuildingDeviceStruct) bool
+	// Updates — push-канал с состояниями устройств; опрос продолжается параллельно
+	Updates <-chan *DaichiBuildingDeviceStruct
+}
+
+// CommandNotAppliedError — устройство не отразило команду до истечения ожидания
+type CommandNotAppliedError struct {
+	DeviceID  int
+	LastState *DaichiBuildingDeviceStruct // Последнее полученное состояние
+	Err       error                       // Причина прекращения ожидания
+}
+
+// Error реализует интерфейс error
+func (e *CommandNotAppliedError) Error() string {
+	return fmt.Sprintf("command not applied to device %d: %v", e.DeviceID, e.Err)
+}
+
+// Unwrap позволяет сравнивать через errors.Is с ErrCommandNotApplied и причиной
+func (e *CommandNotAppliedError) Unwrap() []error {
+	return []error{ErrCommandNotApplied, e.Err}
+}
+
+// temperatureTolerance — допустимое расхождение целевой температуры в состоянии и в команде
+const temperatureTolerance = TargetTemperatureStep / 2
+
+// commandMatcher — строит проверку состояния для команды по каталогу функций устройства.
+// Возвращает nil, если для функции нет автоматической проверки.
+func (c *DaichiClient) commandMatcher(ctx context.Context, deviceID int, control DeviceControlRequest) (func(*DaichiBuildingDeviceStruct) bool, error) {
+	catalog, err := c.deviceFunctions(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := catalog.ByID(control.Value.FunctionID)
+	if !ok {
+		return nil, nil
+	}
+
+	if fn.Name == FunctionNamePower {
+		var want bool
+		switch {
+		case control.Value.IsOn != nil:
+			want = *control.Value.IsOn
+		case control.Value.Value != nil:
+			want = *control.Value.Value != 0
+		default:
+			return nil, nil
+		}
+		return func(d *DaichiBuildingDeviceStruct) bool {
+			return d.State.IsOn == want
+		}, nil
+	}
+
+	if control.Value.Value == nil {
+		return nil, nil
+	}
+	value := *control.Value.Value
+
+	switch fn.Name {
+	case FunctionNameTemperature:
+		return func(d *DaichiBuildingDeviceStruct) bool {
+			target := d.OperatingState().TargetTemperature
+			return target != nil && math.Abs(*target-value) <= temperatureTolerance
+		}, nil
+
+	case FunctionNameMode:
+		want, ok := modeByName(fn.valueName(value))
+		if !ok {
+			return nil, nil
+		}
+		return func(d *DaichiBuildingDeviceStruct) bool {
+			return d.OperatingState().Mode == want
+		}, nil
+
+	case FunctionNameFanSpeed:
+		want := int(value)
+		if name := fn.valueName(value); name != "" {
+			if speed, ok := parseFanSpeedIcon("fan_" + name); ok {
+				want = speed
+			}
+		}
+		return func(d *DaichiBuildingDeviceStruct) bool {
+			speed := d.OperatingState().FanSpeed
+			return speed != nil && *speed == want
+		}, nil
+
+	case FunctionNameSwing:
+		want, ok := swingByName(fn.valueName(value))
+		if !ok {
+			return nil, nil
+		}
+		return func(d *DaichiBuildingDeviceStruct) bool {
+			swing := d.OperatingState().Swing
+			return swing != nil && *swing == want
+		}, nil
+	}
+	return nil, nil
+}
+
+// valueName — название значения enum-функции; пустая строка для числовых функций
+func (f *DeviceFunction) valueName(value float64) string {
+	for _, v := range f.Values {
+		if v.Value == value {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// modeByName — режим по названию значения из каталога
+func modeByName(name string) (DeviceMode, bool) {
+	mode, ok := stateModeIcons[normalizeIconName(name)]
+	return mode, ok
+}
+
+// swingByName — режим качания по названию значения из каталога
+func swingByName(name string) (SwingMode, bool) {
+	name = normalizeIconName(name)
+	for swing := SwingOff; swing <= SwingBoth; swing++ {
+		if swing.String() == name {
+			return swing, true
+		}
+	}
+	swing, ok := stateSwingIcons[name]
+	return swing, ok
+}
+
+// ControlDeviceAndWait — отправляет команду и ждет, пока устройство ее отразит
+func (c *DaichiClient) ControlDeviceAndWait(ctx context.Context, deviceID int, control DeviceControlRequest, opts WaitOptions) (*DaichiBuildingDeviceStruct, error) {
+	match := opts.Match
+	if match == nil {
+		var err error
+		if match, err = c.commandMatcher(ctx, deviceID, control); err != nil {
+			return nil, err
+		}
+	}
+	if match == nil {
+		return nil, &ValidationError{
+			Field:  "WaitOptions.Match",
+			Value:  control.Value.FunctionID,
+			Reason: "required for this function",
+		}
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultWaitPollInterval
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		if opts.Timeout <= 0 {
+			opts.Timeout = DefaultWaitTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	last, err := c.ControlDevice(ctx, deviceID, control)
+	if err != nil {
+		return nil, err
+	}
+	if match(last) {
+		return last, nil
+	}
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.Logger.Warn("Device %d did not apply command %d: %v", deviceID, control.CmdID, ctx.Err())
+			return nil, &CommandNotAppliedError{DeviceID: deviceID, LastState: last, Err: ctx.Err()}
+
+		case state, ok := <-opts.Updates:
+			if !ok {
+				opts.Updates = nil
+				continue
+			}
+			if state == nil || state.ID != deviceID {
+				continue
+			}
+			last = state
+
+		case <-ticker.C:
+			state, err := c.GetDeviceState(ctx, deviceID)
+			if err != nil {
+				c.Logger.Warn("Failed to poll device %d state: %v", deviceID, err)
+				continue
+			}
+			last = state
+		}
+
+		if match(last) {
+			c.Logger.Info("Device %d confirmed command", deviceID)
+			return last, nil
+		}
+	}
+}
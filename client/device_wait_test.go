@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appliedState — состояние, которое устройство сообщает после применения команд
+const appliedState = `{"done":true,"data":{"id":1,"status":"connected","state":{"isOn":true,` +
+	`"info":{"iconNames":["cool","fan_speed_3","swing_vertical"]},` +
+	`"details":[{"details":[{"iconName":"temperature","text":"24°"}]}]}}}`
+
+// newWaitAPI — API, которое отвечает на команду старым состоянием, а при опросе — appliedState
+func newWaitAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	functions, err := json.Marshal(DefaultFunctionCatalog(1).Functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/token"):
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+		case strings.HasSuffix(path, "/functions"):
+			fmt.Fprintf(w, `{"done":true,"data":%s}`, functions)
+		case strings.HasSuffix(path, "/ctrl"):
+			fmt.Fprint(w, `{"done":true,"data":{"id":1,"status":"connected","state":{"isOn":false}}}`)
+		default:
+			fmt.Fprint(w, appliedState)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestControlDeviceAndWaitBuildsMatcherFromCatalog(t *testing.T) {
+	srv := newWaitAPI(t)
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	on := true
+	tests := []struct {
+		name    string
+		value   DeviceFunctionControl
+		applied bool
+	}{
+		{"power", DeviceFunctionControl{FunctionID: FunctionPower, IsOn: &on}, true},
+		{"temperature", numberFunction(FunctionTemperature, 24), true},
+		{"temperature not reached", numberFunction(FunctionTemperature, 26), false},
+		{"mode", numberFunction(FunctionMode, float64(ModeCool)), true},
+		{"other mode", numberFunction(FunctionMode, float64(ModeHeat)), false},
+		{"fan speed", numberFunction(FunctionFanSpeed, 3), true},
+		{"other fan speed", numberFunction(FunctionFanSpeed, 1), false},
+		{"swing", numberFunction(FunctionSwing, float64(SwingVertical)), true},
+		{"other swing", numberFunction(FunctionSwing, float64(SwingBoth)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := WaitOptions{PollInterval: 5 * time.Millisecond, Timeout: 50 * time.Millisecond}
+			_, err := c.ControlDeviceAndWait(ctx, 1, DeviceControlRequest{Value: tt.value}, opts)
+			if tt.applied && err != nil {
+				t.Errorf("err = %v, want command confirmed", err)
+			}
+			if !tt.applied && !errors.Is(err, ErrCommandNotApplied) {
+				t.Errorf("err = %v, want ErrCommandNotApplied", err)
+			}
+		})
+	}
+}
+
+func TestControlDeviceAndWaitRequiresMatchForUnknownFunction(t *testing.T) {
+	srv := newWaitAPI(t)
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	_, err = c.ControlDeviceAndWait(ctx, 1, DeviceControlRequest{Value: numberFunction(999, 1)}, WaitOptions{})
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("err = %v, want ErrInvalidValue", err)
+	}
+}
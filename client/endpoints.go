@@ -0,0 +1,108 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Endpoints — пути API относительно базового URL.
+// Пути устройств содержат %d для ID устройства.
+type Endpoints struct {
+	Token           string
+	UserInfo        string
+	Buildings       string
+	Device          string
+	DeviceControl   string
+	DeviceFunctions string
+}
+
+// DefaultEndpoints — пути API по умолчанию
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		Token:           DefaultTokenPath,
+		UserInfo:        DefaultUserInfoPath,
+		Buildings:       DefaultBuildingsPath,
+		Device:          DefaultDevicePath,
+		DeviceControl:   DefaultDeviceControlPath,
+		DeviceFunctions: DefaultDeviceFunctionsPath,
+	}
+}
+
+// WithBaseURL — устанавливает базовый URL API (например, staging или httptest)
+func WithBaseURL(baseURL string) Option {
+	return func(c *DaichiClient) {
+		if err := validateBaseURL(baseURL); err != nil {
+			c.optionErr = err
+			return
+		}
+		c.baseURL = baseURL
+	}
+}
+
+// WithEndpoints — переопределяет пути API; пустые поля остаются по умолчанию
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(c *DaichiClient) {
+		overrides := []struct {
+			target   *string
+			path     string
+			deviceID bool
+		}{
+			{&c.endpoints.Token, endpoints.Token, false},
+			{&c.endpoints.UserInfo, endpoints.UserInfo, false},
+			{&c.endpoints.Buildings, endpoints.Buildings, false},
+			{&c.endpoints.Device, endpoints.Device, true},
+			{&c.endpoints.DeviceControl, endpoints.DeviceControl, true},
+			{&c.endpoints.DeviceFunctions, endpoints.DeviceFunctions, true},
+		}
+
+		for _, o := range overrides {
+			if o.path == "" {
+				continue
+			}
+			if err := validatePath(o.path, o.deviceID); err != nil {
+				c.optionErr = err
+				return
+			}
+			*o.target = o.path
+		}
+	}
+}
+
+// validateBaseURL — проверяет базовый URL
+func validateBaseURL(baseURL string) error {
+	if baseURL == "" || strings.ContainsAny(baseURL, " \t\r\n") {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, baseURL)
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q must be an absolute http(s) URL", ErrInvalidURL, baseURL)
+	}
+	return nil
+}
+
+// validatePath — проверяет путь эндпоинта
+func validatePath(path string, deviceID bool) error {
+	if strings.ContainsAny(path, " \t\r\n?#") {
+		return fmt.Errorf("%w: path %q", ErrInvalidURL, path)
+	}
+	if deviceID && strings.Count(path, "%d") != 1 {
+		return fmt.Errorf("%w: path %q must contain exactly one %%d", ErrInvalidURL, path)
+	}
+	return nil
+}
+
+// endpointURL — собирает полный URL эндпоинта
+func (c *DaichiClient) endpointURL(path string, args ...any) (string, error) {
+	if c.optionErr != nil {
+		return "", c.optionErr
+	}
+	if len(args) > 0 {
+		path = fmt.Sprintf(path, args...)
+	}
+	return url.JoinPath(c.baseURL, path)
+}
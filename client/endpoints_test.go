@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"defaults", nil, false},
+		{"base URL", []Option{WithBaseURL("https://staging.example.com/api/v4")}, false},
+		{"endpoints", []Option{WithEndpoints(Endpoints{Device: "/v2/devices/%d"})}, false},
+		{"relative base URL", []Option{WithBaseURL("staging.example.com")}, true},
+		{"base URL with spaces", []Option{WithBaseURL("https://example.com/ api")}, true},
+		{"path without device ID", []Option{WithEndpoints(Endpoints{DeviceControl: "/devices/ctrl"})}, true},
+		{"path with query", []Option{WithEndpoints(Endpoints{Buildings: "/buildings?all=1"})}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewDaichiClient(append(tt.opts, WithNoLogs())...)
+			err := c.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("Validate() = %v, want ErrInvalidURL", err)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizedDaichiClientRejectsInvalidOptions(t *testing.T) {
+	_, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password",
+		WithBaseURL("not a url"), WithNoLogs())
+	if !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("err = %v, want ErrInvalidURL", err)
+	}
+}
@@ -0,0 +1,225 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Значения перечислений, кроме StatusConnected (его проверяет IsOnline), не подтверждены
+// документацией API и являются предположениями SDK: захваченных ответов API с ними нет.
+// Неизвестные значения сохраняются как есть, IsKnown только решает, писать ли предупреждение в лог.
+
+// DeviceStatus — статус подключения устройства
+type DeviceStatus string
+
+const (
+	StatusConnected    DeviceStatus = "connected"
+	StatusDisconnected DeviceStatus = "disconnected"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (s DeviceStatus) IsKnown() bool {
+	return oneOf(s, StatusConnected, StatusDisconnected)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (s *DeviceStatus) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, s) }
+
+// Access — уровень доступа пользователя к зданию или устройству
+type Access string
+
+const (
+	AccessOwner   Access = "OWNER"
+	AccessFull    Access = "FULL"
+	AccessControl Access = "CONTROL"
+	AccessView    Access = "VIEW"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (a Access) IsKnown() bool {
+	return oneOf(a, AccessOwner, AccessFull, AccessControl, AccessView)
+}
+
+// CanControl — разрешает ли доступ управлять устройством
+func (a Access) CanControl() bool {
+	return oneOf(a, AccessOwner, AccessFull, AccessControl)
+}
+
+// IsOwner — является ли пользователь владельцем
+func (a Access) IsOwner() bool {
+	return oneOf(a, AccessOwner)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (a *Access) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, a) }
+
+// CloudType — облако, через которое работает устройство
+type CloudType string
+
+const (
+	CloudDaichi CloudType = "DAICHI"
+	CloudMidea  CloudType = "MIDEA"
+	CloudHaier  CloudType = "HAIER"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t CloudType) IsKnown() bool {
+	return oneOf(t, CloudDaichi, CloudMidea, CloudHaier)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *CloudType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// DistributionType — канал распространения устройства
+type DistributionType string
+
+const (
+	DistributionRetail DistributionType = "RETAIL"
+	DistributionB2B    DistributionType = "B2B"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t DistributionType) IsKnown() bool {
+	return oneOf(t, DistributionRetail, DistributionB2B)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *DistributionType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// DeviceControlType — способ управления устройством
+type DeviceControlType string
+
+const (
+	ControlTypeCloud  DeviceControlType = "CLOUD"
+	ControlTypeBle    DeviceControlType = "BLE"
+	ControlTypeHybrid DeviceControlType = "HYBRID"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t DeviceControlType) IsKnown() bool {
+	return oneOf(t, ControlTypeCloud, ControlTypeBle, ControlTypeHybrid)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *DeviceControlType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// FirmwareType — тип прошивки Wi-Fi модуля
+type FirmwareType string
+
+const (
+	FirmwareDaichi FirmwareType = "DAICHI"
+	FirmwareMidea  FirmwareType = "MIDEA"
+	FirmwareESP    FirmwareType = "ESP"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t FirmwareType) IsKnown() bool {
+	return oneOf(t, FirmwareDaichi, FirmwareMidea, FirmwareESP)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *FirmwareType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// DeviceType — тип устройства
+type DeviceType string
+
+const (
+	DeviceTypeConditioner DeviceType = "CONDITIONER"
+	DeviceTypeVRF         DeviceType = "VRF"
+	DeviceTypeThermostat  DeviceType = "THERMOSTAT"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t DeviceType) IsKnown() bool {
+	return oneOf(t, DeviceTypeConditioner, DeviceTypeVRF, DeviceTypeThermostat)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *DeviceType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// UserType — тип учетной записи
+type UserType string
+
+const (
+	UserTypeIndividual UserType = "INDIVIDUAL"
+	UserTypeCompany    UserType = "COMPANY"
+	UserTypeInstaller  UserType = "INSTALLER"
+)
+
+// IsKnown — проверяет, что значение известно SDK
+func (t UserType) IsKnown() bool {
+	return oneOf(t, UserTypeIndividual, UserTypeCompany, UserTypeInstaller)
+}
+
+// UnmarshalJSON принимает неизвестные значения без ошибки
+func (t *UserType) UnmarshalJSON(data []byte) error { return unmarshalEnum(data, t) }
+
+// oneOf — сравнивает значение с известными без учета регистра
+func oneOf[T ~string](value T, known ...T) bool {
+	for _, k := range known {
+		if strings.EqualFold(string(value), string(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmarshalEnum — декодирует строку; null и нестроковые значения не считаются ошибкой
+func unmarshalEnum[T ~string](data []byte, value *T) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var raw any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		if raw != nil {
+			s = fmt.Sprint(raw)
+		}
+	}
+	*value = T(s)
+	return nil
+}
+
+// EnumValue — типизированное значение API
+type EnumValue interface {
+	IsKnown() bool
+}
+
+// WarnUnknownEnums — сообщает о неизвестных значениях; каждое значение — один раз на логгер
+func (l *Logger) WarnUnknownEnums(values ...EnumValue) {
+	for _, value := range values {
+		s := fmt.Sprint(value)
+		if s == "" || value.IsKnown() {
+			continue
+		}
+		key := fmt.Sprintf("%T:%s", value, s)
+		if _, loaded := l.reportedEnums.LoadOrStore(key, true); loaded {
+			continue
+		}
+		l.Warn("Unknown %T value from API: %q", value, s)
+	}
+}
+
+// EnumValues — типизированные значения устройства
+func (d *DaichiBuildingDeviceStruct) EnumValues() []EnumValue {
+	return []EnumValue{d.Status, d.Access, d.CloudType, d.DistributionType, d.DeviceControlType, d.FirmwareType, d.DeviceType}
+}
+
+// EnumValues — типизированные значения здания и его устройств
+func (b *DaichiBuilding) EnumValues() []EnumValue {
+	values := []EnumValue{b.Access, b.CloudType}
+	for i := range b.Places {
+		values = append(values, b.Places[i].EnumValues()...)
+	}
+	return values
+}
+
+// EnumValues — типизированные значения пользователя
+func (u *DaichiUser) EnumValues() []EnumValue {
+	values := []EnumValue{u.UserType}
+	for _, request := range u.AccessRequests {
+		values = append(values, request.Access)
+	}
+	return values
+}
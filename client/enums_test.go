@@ -0,0 +1,34 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWarnUnknownEnumsPerLogger(t *testing.T) {
+	var d DaichiBuildingDeviceStruct
+	if err := json.Unmarshal([]byte(`{"status":"connected","access":"GUEST","deviceType":"HEAT_PUMP"}`), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	// Первый логгер сообщает о значениях один раз, второй — независимо от первого
+	var first, second bytes.Buffer
+	firstLogger, secondLogger := NewLogger(LogWarn, &first), NewLogger(LogWarn, &second)
+	for _, logger := range []*Logger{firstLogger, firstLogger, secondLogger} {
+		logger.WarnUnknownEnums(d.EnumValues()...)
+	}
+
+	for name, out := range map[string]*bytes.Buffer{"first": &first, "second": &second} {
+		logs := out.String()
+		for _, value := range []string{`"GUEST"`, `"HEAT_PUMP"`} {
+			if n := strings.Count(logs, value); n != 1 {
+				t.Errorf("%s logger reported %s %d times:\n%s", name, value, n, logs)
+			}
+		}
+		if strings.Contains(logs, "connected") {
+			t.Errorf("%s logger reported a known value:\n%s", name, logs)
+		}
+	}
+}
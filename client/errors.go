@@ -1,18 +1,45 @@
 package client
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Sentinel ошибки
 var (
-	ErrMissingCredentials = errors.New("username and password must be set")
-	ErrTokenNotFound      = errors.New("access_token not found in response")
-	ErrTokenRefreshFailed = errors.New("failed to refresh token")
-	ErrRequestFailed      = errors.New("request failed")
-	ErrCircuitBreakerOpen = errors.New("circuit breaker is open")
-	ErrInvalidAPIResponse = errors.New("invalid API response")
-	ErrMethodNotAllowed   = errors.New("method not allowed (405)")
-	ErrTokenExpired       = errors.New("token expired")
-	ErrInvalidURL         = errors.New("invalid URL: contains spaces or malformed")
-	ErrEndpointNotFound   = errors.New("API endpoint not found (404)")
-	ErrUnsupportedMethod  = errors.New("unsupported method for route")
+	ErrMissingCredentials   = errors.New("username and password must be set")
+	ErrTokenNotFound        = errors.New("access_token not found in response")
+	ErrTokenRefreshFailed   = errors.New("failed to refresh token")
+	ErrRequestFailed        = errors.New("request failed")
+	ErrCircuitBreakerOpen   = errors.New("circuit breaker is open")
+	ErrInvalidAPIResponse   = errors.New("invalid API response")
+	ErrMethodNotAllowed     = errors.New("method not allowed (405)")
+	ErrTokenExpired         = errors.New("token expired")
+	ErrInvalidURL           = errors.New("invalid URL: contains spaces or malformed")
+	ErrEndpointNotFound     = errors.New("API endpoint not found (404)")
+	ErrUnsupportedMethod    = errors.New("unsupported method for route")
+	ErrInvalidValue         = errors.New("invalid control value")
+	ErrControlConflict      = errors.New("control command conflicts with device state")
+	ErrCommandNotApplied    = errors.New("command was not applied by device")
+	ErrTransportUnavailable = errors.New("command transport unavailable")
+	ErrCommandTimeout       = errors.New("no response to command")
+	ErrTokenNotStored       = errors.New("no token in token store")
+	ErrSharedAccountState   = errors.New("token store and circuit breaker cannot be shared between accounts")
 )
+
+// ValidationError — ошибка проверки значения до отправки команды
+type ValidationError struct {
+	Field  string
+	Value  any
+	Reason string
+}
+
+// Error реализует интерфейс error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s value %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// Unwrap позволяет сравнивать через errors.Is(err, ErrInvalidValue)
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidValue
+}
@@ -25,28 +25,75 @@ type APIResponse[T any] struct {
 
 // Константы
 const (
-	DefaultAPIURL        = "https://web.daichicloud.ru/api/v4 "
-	DefaultUserInfoPath  = "/user"
-	DefaultBuildingsPath = "/buildings"
-	DefaultTokenPath     = "/token"
-	DefaultClientID      = "sOJO7B6SqgaKudTfCzqLAy540cCuDzpI"
+	DefaultAPIURL              = "https://web.daichicloud.ru/api/v4"
+	DefaultUserInfoPath        = "/user"
+	DefaultBuildingsPath       = "/buildings"
+	DefaultTokenPath           = "/token"
+	DefaultDevicePath          = "/devices/%d"
+	DefaultDeviceControlPath   = "/devices/%d/ctrl"
+	DefaultDeviceFunctionsPath = "/devices/%d/functions"
+	DefaultClientID            = "sOJO7B6SqgaKudTfCzqLAy540cCuDzpI"
 )
 
 // DaichiClient — клиент для работы с API
 type DaichiClient struct {
-	clientID   string
-	username   string
-	password   string
-	httpClient *http.Client
-	token      string
-	tokenMutex sync.RWMutex
-	Logger     *Logger
-	breaker    *circuitbreaker.CircuitBreaker
+	clientID       string
+	username       string
+	password       string
+	httpClient     *http.Client
+	token          string
+	tokenExpiresAt time.Time
+	tokenMutex     sync.RWMutex
+	Logger         *Logger
+	breaker        *circuitbreaker.CircuitBreaker
+	breakerStats   breakerStats
+
+	refreshBefore   time.Duration
+	refreshTimer    *time.Timer
+	refreshMutex    sync.Mutex
+	refreshFailures int // Неудачные фоновые обновления подряд
+	closed          bool
+
+	inflight      *refreshCall
+	inflightMutex sync.Mutex
+
+	middlewares []Middleware
+	tokenStore  TokenStore
+	retryPolicy *RetryPolicy
+
+	rateLimit        *rateLimitConfig
+	controlRateLimit *rateLimitConfig
+	readLimiter      *rateLimiter
+	controlLimiter   *rateLimiter
+
+	credentialsProvider CredentialsProvider
+
+	baseURL   string
+	endpoints Endpoints
+	optionErr error // Ошибка конфигурации из опций, возвращается Validate и при запросах
+
+	functions            map[int]*DeviceFunctionCatalog
+	functionsUnsupported map[int]error // Устройства, для которых сервер не поддерживает /functions
+	functionsMutex       sync.RWMutex
+	fallbackCatalog      func(deviceID int) *DeviceFunctionCatalog
+
+	locations      map[int]*time.Location // Часовые пояса зданий по ID из GetBuildings
+	locationsMutex sync.RWMutex
+
+	transport      Transport
+	publisher      CommandPublisher
+	publisherMutex sync.RWMutex
+
+	schemaDriftReporter SchemaDriftReporter
+	reportedDrift       sync.Map // Поля "тип.поле", о которых уже сообщено
 }
 
 // Option — функциональный тип для настройки клиента
 type Option func(*DaichiClient)
 
+// Middleware — обертка над транспортом HTTP (прокси, подпись запросов, перехватчики в тестах)
+type Middleware func(http.RoundTripper) http.RoundTripper
+
 // WithClientID — устанавливает ClientID
 func WithClientID(id string) Option {
 	return func(c *DaichiClient) {
@@ -96,6 +143,41 @@ func WithCircuitBreaker(b *circuitbreaker.CircuitBreaker) Option {
 	}
 }
 
+// WithHTTPClient — устанавливает собственный http.Client.
+// Клиент копируется; его Transport (или http.DefaultTransport) становится базовым транспортом.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *DaichiClient) {
+		if httpClient == nil {
+			return
+		}
+		hc := *httpClient
+		c.httpClient = &hc
+	}
+}
+
+// WithMiddleware — добавляет обертки транспорта.
+// Первая обертка — внешняя: middlewares → AuthRoundTripper → повторы → ограничение частоты →
+// Circuit Breaker → базовый транспорт.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *DaichiClient) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// WithTransport — устанавливает транспорт для команд управления
+func WithTransport(transport Transport) Option {
+	return func(c *DaichiClient) {
+		c.transport = transport
+	}
+}
+
+// WithCommandPublisher — устанавливает альтернативный транспорт команд
+func WithCommandPublisher(publisher CommandPublisher) Option {
+	return func(c *DaichiClient) {
+		c.publisher = publisher
+	}
+}
+
 // WithDebug — включает дебаг-логи
 func WithDebug(debug bool) Option {
 	return func(c *DaichiClient) {
@@ -122,15 +204,17 @@ func NewDaichiClient(opts ...Option) *DaichiClient {
 		httpClient: &http.Client{
 			Timeout: 5 * time.Second,
 		},
-		token: "",
+		token:                "",
+		baseURL:              DefaultAPIURL,
+		refreshBefore:        DefaultTokenRefreshBefore,
+		endpoints:            DefaultEndpoints(),
+		functions:            make(map[int]*DeviceFunctionCatalog),
+		functionsUnsupported: make(map[int]error),
 		breaker: NewCircuitBreaker(CircuitBreakerConfig{
 			Name:        "daichi_api_breaker",
 			MaxRequests: 5,
 			Interval:    30 * time.Second,
 			Timeout:     10 * time.Second,
-			IsError: func(err error) bool {
-				return err != nil
-			},
 		}),
 	}
 
@@ -138,50 +222,112 @@ func NewDaichiClient(opts ...Option) *DaichiClient {
 		opt(client)
 	}
 
+	client.buildRateLimiters()
+	client.httpClient.Transport = client.buildTransport(client.httpClient.Transport)
 	return client
 }
 
+// Validate — возвращает ошибку конфигурации из опций (например, ErrInvalidURL
+// из WithBaseURL или WithEndpoints), не дожидаясь первого запроса
+func (c *DaichiClient) Validate() error {
+	return c.optionErr
+}
+
+// buildTransport — собирает цепочку транспорта: middlewares → AuthRoundTripper
+// (токен и повторная авторизация при 401) → повторы → ограничение частоты → Circuit Breaker → базовый транспорт
+func (c *DaichiClient) buildTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var inner http.RoundTripper = &breakerRoundTripper{
+		transport: base,
+		breaker:   c.breaker,
+		stats:     &c.breakerStats,
+		logger:    c.Logger,
+	}
+	if c.readLimiter != nil || c.controlLimiter != nil {
+		inner = &rateLimitRoundTripper{transport: inner, read: c.readLimiter, control: c.controlLimiter, logger: c.Logger}
+	}
+	if c.retryPolicy != nil {
+		inner = &retryRoundTripper{transport: inner, policy: c.retryPolicy, logger: c.Logger}
+	}
+
+	var transport http.RoundTripper = &AuthRoundTripper{
+		Transport:   inner,
+		TokenSource: c.currentToken,
+		RefreshFn:   c.refreshToken,
+		Logger:      c.Logger,
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		transport = c.middlewares[i](transport)
+	}
+	return transport
+}
+
+// currentToken — возвращает текущий токен
+func (c *DaichiClient) currentToken() string {
+	c.tokenMutex.RLock()
+	defer c.tokenMutex.RUnlock()
+	return c.token
+}
+
+// refreshToken — получает новый токен после 401; отклоненный токен удаляется из хранилища.
+// Параллельные вызовы объединяются в одно обращение к /token.
+func (c *DaichiClient) refreshToken(ctx context.Context) (string, error) {
+	return c.refreshOnce(ctx, func(ctx context.Context) error {
+		if c.tokenStore != nil {
+			if err := c.tokenStore.Clear(ctx); err != nil {
+				c.Logger.Warn("Failed to clear stored token: %v", err)
+			}
+		}
+		return c.GetToken(ctx)
+	})
+}
+
 // buildTokenRequest — создает POST-запрос для получения токена
-func buildTokenRequest(ctx context.Context, c *DaichiClient) (*http.Request, error) {
+func buildTokenRequest(ctx context.Context, c *DaichiClient, creds Credentials) (*http.Request, error) {
 	values := url.Values{
 		"grant_type": {"password"},
-		"email":      {c.username},
-		"password":   {c.password},
+		"email":      {creds.Username},
+		"password":   {creds.Password},
 		"clientId":   {c.clientID},
 	}
 
-	reqURL, err := url.JoinPath(strings.TrimSpace(DefaultAPIURL), strings.TrimSpace(DefaultTokenPath))
+	reqURL, err := c.endpointURL(c.endpoints.Token)
 	if err != nil {
 		c.Logger.Error("Failed to build token URL: %v", err)
 		return nil, fmt.Errorf("invalid token URL: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(values.Encode()))
+	// Запрос токена не проходит через авторизацию AuthRoundTripper
+	req, err := http.NewRequestWithContext(withoutAuth(ctx), "POST", reqURL, strings.NewReader(values.Encode()))
 	if err != nil {
 		c.Logger.Error("Failed to create token request: %v", err)
 		return nil, fmt.Errorf("failed to create token request: %w", err)
 	}
 
-	req.URL.RawQuery = values.Encode()
+	// Учетные данные передаются только в теле запроса и не логируются
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
-	c.Logger.Debug("Token request URL: %s?%s", reqURL, req.URL.RawQuery)
+	c.Logger.Debug("Token request URL: %s", reqURL)
 	return req, nil
 }
 
 // fetchToken — общая логика получения токена
-func (c *DaichiClient) fetchToken(ctx context.Context, req *http.Request) (string, error) {
-	resp, err := c.httpClient.Do(req)
+func (c *DaichiClient) fetchToken(ctx context.Context, req *http.Request) (string, time.Time, error) {
+	resp, err := c.do(req)
 	if err != nil {
 		c.Logger.Error("Token request failed: %v", err)
-		return "", fmt.Errorf("token request failed: %w", err)
+		return "", time.Time{}, fmt.Errorf("token request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		c.Logger.Error("Failed to read token response: %v", err)
-		return "", fmt.Errorf("failed to read token response: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to read token response: %w", err)
 	}
 
 	var result struct {
@@ -189,65 +335,68 @@ func (c *DaichiClient) fetchToken(ctx context.Context, req *http.Request) (strin
 		Errors         any  `json:"errors"`
 		UpdateRequired bool `json:"updateRequired"`
 		Data           struct {
-			Token string `json:"access_token"`
+			Token     string `json:"access_token"`
+			ExpiresIn int64  `json:"expires_in"`
 		} `json:"data"`
 	}
 
 	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&result); err != nil {
 		c.Logger.Error("Failed to decode token response: %v", err)
-		return "", fmt.Errorf("token unmarshal failed: %w", err)
+		return "", time.Time{}, fmt.Errorf("token unmarshal failed: %w", err)
 	}
 
 	if !result.Done {
-		c.Logger.Error("Token request failed: %v", result.Errors)
-		return "", fmt.Errorf("token request failed: %v", result.Errors)
+		apiErr := newAPIError(resp.StatusCode, resp.Header, body)
+		c.Logger.Error("Token request failed: %v", apiErr)
+		return "", time.Time{}, apiErr
 	}
 
 	if result.UpdateRequired {
-		return "", ErrTokenRefreshFailed
+		return "", time.Time{}, ErrTokenRefreshFailed
 	}
 
 	if result.Errors != nil {
-		return "", fmt.Errorf("server returned errors: %v", result.Errors)
+		return "", time.Time{}, newAPIError(resp.StatusCode, resp.Header, body)
 	}
 
 	token := result.Data.Token
 	if token == "" {
-		return "", ErrTokenNotFound
+		return "", time.Time{}, ErrTokenNotFound
 	}
 
-	c.Logger.Info("Token received: %s", token)
-	return token, nil
+	expiresAt := tokenExpiry(token, result.Data.ExpiresIn)
+
+	c.Logger.Info("Token received: %s", redactSecret(token))
+	return token, expiresAt, nil
 }
 
 // GetToken — авторизация через /token
 func (c *DaichiClient) GetToken(ctx context.Context) error {
-	if c.username == "" || c.password == "" {
-		c.Logger.Error("Username and password must be set")
-		return ErrMissingCredentials
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		c.Logger.Error("Failed to get credentials: %v", err)
+		return err
 	}
 
-	req, err := buildTokenRequest(ctx, c)
+	req, err := buildTokenRequest(ctx, c, creds)
 	if err != nil {
 		return err
 	}
 
-	token, err := c.fetchToken(ctx, req)
+	token, expiresAt, err := c.fetchToken(ctx, req)
 	if err != nil {
 		c.Logger.Error("Failed to fetch token: %v", err)
 		return err
 	}
 
-	c.tokenMutex.Lock()
-	c.token = token
-	c.tokenMutex.Unlock()
-
+	c.setToken(token, expiresAt)
+	c.saveToken(ctx, &StoredToken{Account: creds.Username, AccessToken: token, ExpiresAt: expiresAt})
 	return nil
 }
 
 // buildUserInfoRequest — создает GET-запрос для получения информации о пользователе
 func buildUserInfoRequest(ctx context.Context, c *DaichiClient) (*http.Request, error) {
-	reqURL, err := url.JoinPath(strings.TrimSpace(DefaultAPIURL), strings.TrimSpace(DefaultUserInfoPath))
+	reqURL, err := c.endpointURL(c.endpoints.UserInfo)
 	if err != nil {
 		c.Logger.Error("Failed to build user info URL: %v", err)
 		return nil, fmt.Errorf("invalid user info URL: %w", err)
@@ -259,14 +408,6 @@ func buildUserInfoRequest(ctx context.Context, c *DaichiClient) (*http.Request,
 		return nil, fmt.Errorf("failed to create user info request: %w", err)
 	}
 
-	c.tokenMutex.RLock()
-	token := c.token
-	c.tokenMutex.RUnlock()
-
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
-
 	req.Header.Set("Accept", "application/json")
 	c.Logger.Debug("User info request URL: %s", reqURL)
 	return req, nil
@@ -279,7 +420,7 @@ func (c *DaichiClient) GetUserInfo(ctx context.Context) (*DaichiUser, error) {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		c.Logger.Error("API unreachable: %v", err)
 		return nil, fmt.Errorf("API unreachable: %w", err)
@@ -288,18 +429,18 @@ func (c *DaichiClient) GetUserInfo(ctx context.Context) (*DaichiUser, error) {
 
 	if resp.StatusCode == http.StatusNotFound {
 		c.Logger.Error("API endpoint not found (404): %s", req.URL.String())
-		return nil, ErrEndpointNotFound
+		return nil, apiErrorFromResponse(resp)
 	}
 
 	if resp.StatusCode == http.StatusMethodNotAllowed {
 		c.Logger.Error("Method Not Allowed (405): %s", req.URL.String())
-		return nil, ErrMethodNotAllowed
+		return nil, apiErrorFromResponse(resp)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		c.Logger.Error("Non-200 status code: %d, response: %s", resp.StatusCode, body)
-		return nil, fmt.Errorf("non-200 status code: %d", resp.StatusCode)
+		c.Logger.Error("Non-200 status code: %d, response: %s", resp.StatusCode, redactJSON(body))
+		return nil, newAPIError(resp.StatusCode, resp.Header, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -308,7 +449,7 @@ func (c *DaichiClient) GetUserInfo(ctx context.Context) (*DaichiUser, error) {
 		return nil, fmt.Errorf("failed to read user info response: %w", err)
 	}
 
-	c.Logger.Debug("User info raw: %s", body)
+	c.Logger.Debug("User info raw: %s", redactJSON(body))
 
 	// ✅ Десериализуем через APIResponse[DaichiUser]
 	var response APIResponse[DaichiUser]
@@ -318,17 +459,20 @@ func (c *DaichiClient) GetUserInfo(ctx context.Context) (*DaichiUser, error) {
 	}
 
 	if !response.Done {
-		c.Logger.Error("Server returned errors: %v", response.Errors)
-		return nil, fmt.Errorf("server errors: %v", response.Errors)
+		apiErr := newAPIError(resp.StatusCode, resp.Header, body)
+		c.Logger.Error("Server returned errors: %v", apiErr)
+		return nil, apiErr
 	}
 
-	c.Logger.Info("User info received: %s", string(body))
+	c.Logger.WarnUnknownEnums(response.Data.EnumValues()...)
+	c.reportUserDrift(&response.Data)
+	c.Logger.Info("User info received: %s", redactJSON(body))
 	return &response.Data, nil // ✅ Возвращаем данные из поля data
 }
 
 // buildBuildingsRequest — создает GET-запрос для получения зданий
 func buildBuildingsRequest(ctx context.Context, c *DaichiClient) (*http.Request, error) {
-	reqURL, err := url.JoinPath(strings.TrimSpace(DefaultAPIURL), "buildings")
+	reqURL, err := c.endpointURL(c.endpoints.Buildings)
 	if err != nil {
 		c.Logger.Error("Failed to build buildings URL: %v", err)
 		return nil, fmt.Errorf("invalid buildings URL: %w", err)
@@ -340,24 +484,30 @@ func buildBuildingsRequest(ctx context.Context, c *DaichiClient) (*http.Request,
 		return nil, fmt.Errorf("failed to create buildings request: %w", err)
 	}
 
-	c.tokenMutex.RLock()
-	token := c.token
-	c.tokenMutex.RUnlock()
-
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
 	req.Header.Set("Accept", "application/json")
 	c.Logger.Debug("Buildings request URL: %s", reqURL)
 
 	return req, nil
 }
 
+// GeoTrigger — геотриггер здания: кто и как переключил режим по геозоне
+type GeoTrigger struct {
+	ID       int      `json:"id"`
+	UserID   int      `json:"userId"`
+	FIO      string   `json:"fio,omitempty"`
+	Action   string   `json:"action"` // Например, приход или уход из геозоны
+	GeoZone  int      `json:"geoZone"`
+	IsActive bool     `json:"isActive"`
+	Date     NullTime `json:"date"`
+
+	Raw json.RawMessage `json:"-"` // Исходный JSON, если его форма не совпала с моделью
+}
+
 // DaichiBuilding — структура здания с вложенными устройствами (экспортированная)
 type DaichiBuilding struct {
 	ID          int    `json:"id"`
 	Title       string `json:"title"`
-	Access      string `json:"access"`
+	Access      Access `json:"access"`
 	PlacesCount int    `json:"placesCount"`
 	ShareCount  int    `json:"shareCount"`
 	UTC         int    `json:"utc"`
@@ -369,14 +519,16 @@ type DaichiBuilding struct {
 	GeoState    string                       `json:"geoState"`
 	GeoZone     int                          `json:"geoZone"`
 	Address     string                       `json:"address"`
-	TriggeredBy *interface{}                 `json:"triggeredBy,omitempty"`
+	TriggeredBy *GeoTrigger                  `json:"triggeredBy,omitempty"`
 	HasSettings bool                         `json:"hasSettings"`
-	OwnTrigger  *interface{}                 `json:"ownTrigger,omitempty"`
-	CloudType   string                       `json:"cloudType"`
+	OwnTrigger  *GeoTrigger                  `json:"ownTrigger,omitempty"`
+	CloudType   CloudType                    `json:"cloudType"`
 	TimeZone    string                       `json:"timeZone"`
 	Image       string                       `json:"image"`
 	Slogan      string                       `json:"slogan"`
 	Places      []DaichiBuildingDeviceStruct `json:"places"` // ✅ Теперь структура
+
+	Extra map[string]json.RawMessage `json:"-"` // Поля ответа, которых нет в модели
 }
 
 // GetBuildings — возвращает список зданий
@@ -386,7 +538,7 @@ func (c *DaichiClient) GetBuildings(ctx context.Context) ([]DaichiBuilding, erro
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		c.Logger.Error("API unreachable: %v", err)
 		return nil, fmt.Errorf("API unreachable: %w", err)
@@ -395,18 +547,18 @@ func (c *DaichiClient) GetBuildings(ctx context.Context) ([]DaichiBuilding, erro
 
 	if resp.StatusCode == http.StatusNotFound {
 		c.Logger.Error("API endpoint not found (404): %s", req.URL.String())
-		return nil, ErrEndpointNotFound
+		return nil, apiErrorFromResponse(resp)
 	}
 
 	if resp.StatusCode == http.StatusMethodNotAllowed {
 		c.Logger.Error("Method Not Allowed (405): %s", req.URL.String())
-		return nil, ErrMethodNotAllowed
+		return nil, apiErrorFromResponse(resp)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		c.Logger.Error("Non-200 status code: %d, response: %s", resp.StatusCode, body)
-		return nil, fmt.Errorf("non-200 status code: %d", resp.StatusCode)
+		c.Logger.Error("Non-200 status code: %d, response: %s", resp.StatusCode, redactJSON(body))
+		return nil, newAPIError(resp.StatusCode, resp.Header, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -422,8 +574,9 @@ func (c *DaichiClient) GetBuildings(ctx context.Context) ([]DaichiBuilding, erro
 	}
 
 	if !response.Done {
-		c.Logger.Error("Server returned errors: %v", response.Errors)
-		return nil, fmt.Errorf("server errors: %v", response.Errors)
+		apiErr := newAPIError(resp.StatusCode, resp.Header, body)
+		c.Logger.Error("Server returned errors: %v", apiErr)
+		return nil, apiErr
 	}
 
 	// Преобразуем []DaichiBuildingDeviceStruct → []Device
@@ -433,6 +586,16 @@ func (c *DaichiClient) GetBuildings(ctx context.Context) ([]DaichiBuilding, erro
 		}
 	}
 
+	for i := range response.Data {
+		building := &response.Data[i]
+		c.Logger.WarnUnknownEnums(building.EnumValues()...)
+		c.reportBuildingDrift(building)
+
+		loc := building.Location()
+		c.rememberLocation(building.ID, loc)
+		building.localizeTimes(loc)
+	}
+
 	c.Logger.Info("Buildings received: %d", len(response.Data))
 	return response.Data, nil
 }
@@ -484,9 +647,7 @@ func formatDeviceState(device DaichiBuildingDeviceStruct) string {
 
 // GetDeviceState — получает состояние устройства
 func (c *DaichiClient) GetDeviceState(ctx context.Context, deviceID int) (*DaichiBuildingDeviceStruct, error) {
-	// ✅ Исправленный URL: /devices/{id}, а не /devices/{id}
-	devicePath := fmt.Sprintf("devices/%d", deviceID)
-	reqURL, err := url.JoinPath(strings.TrimSpace(DefaultAPIURL), strings.TrimSpace(devicePath))
+	reqURL, err := c.endpointURL(c.endpoints.Device, deviceID)
 	if err != nil {
 		c.Logger.Error("Failed to build device URL: %v", err)
 		return nil, fmt.Errorf("invalid device URL: %w", err)
@@ -499,18 +660,11 @@ func (c *DaichiClient) GetDeviceState(ctx context.Context, deviceID int) (*Daich
 		return nil, fmt.Errorf("failed to create device request: %w", err)
 	}
 
-	c.tokenMutex.RLock()
-	token := c.token
-	c.tokenMutex.RUnlock()
-
-	if token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
 	req.Header.Set("Accept", "application/json")
 	c.Logger.Debug("Device request URL: %s", reqURL)
 
 	// Отправляем запрос
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		c.Logger.Error("API unreachable: %v", err)
 		return nil, fmt.Errorf("API unreachable: %w", err)
@@ -524,13 +678,13 @@ func (c *DaichiClient) GetDeviceState(ctx context.Context, deviceID int) (*Daich
 		return nil, fmt.Errorf("failed to read device response: %w", err)
 	}
 
-	c.Logger.Debug("Device response raw: \n%s", formatJSON(body))
-	c.Logger.Debug("Device response raw (escaped): %s", body)
+	c.Logger.Debug("Device response raw: \n%s", formatJSON(redactJSON(body)))
+	c.Logger.Debug("Device response raw (escaped): %s", redactJSON(body))
 
 	// Проверяем, что это JSON
 	if !json.Valid(body) {
-		c.Logger.Error("Invalid JSON response: %s", body)
-		return nil, fmt.Errorf("invalid JSON response: %s", body)
+		c.Logger.Error("Invalid JSON response: %s", redactJSON(body))
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAPIResponse, newAPIError(resp.StatusCode, resp.Header, body))
 	}
 
 	// Десериализуем через APIResponse
@@ -541,10 +695,15 @@ func (c *DaichiClient) GetDeviceState(ctx context.Context, deviceID int) (*Daich
 	}
 
 	if !response.Done {
-		c.Logger.Error("Server returned errors: %v", response.Errors)
-		return nil, fmt.Errorf("server errors: %v", response.Errors)
+		apiErr := newAPIError(resp.StatusCode, resp.Header, body)
+		c.Logger.Error("Server returned errors: %v", apiErr)
+		return nil, apiErr
 	}
 
+	c.Logger.WarnUnknownEnums(response.Data.EnumValues()...)
+	c.reportDeviceDrift(&response.Data)
+	c.localizeDevice(&response.Data)
+
 	// ✅ Улучшенный вывод состояния устройства
 	c.Logger.Info("Device state received: \n%s", formatDeviceState(response.Data))
 	return &response.Data, nil
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTripFunc — http.RoundTripper из функции
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var logins, requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			fmt.Fprintf(w, `{"done":true,"data":{"access_token":"token-%d"}}`, logins.Add(1))
+			return
+		}
+		// Первый запрос — истекший токен, второй — сбой сервера, третий — успех
+		switch requests.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusUnauthorized)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			fmt.Fprint(w, `{"done":true,"data":{"id":1}}`)
+		}
+	}))
+	defer srv.Close()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(name string, next http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasSuffix(req.URL.Path, "/token") {
+				mu.Lock()
+				events = append(events, fmt.Sprintf("%s %q", name, req.Header.Get("Authorization")))
+				mu.Unlock()
+			}
+			return next.RoundTrip(req)
+		})
+	}
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.Jitter = 0
+
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password",
+		WithBaseURL(srv.URL),
+		WithNoLogs(),
+		WithRetryPolicy(policy),
+		WithHTTPClient(&http.Client{Transport: record("base", http.DefaultTransport)}),
+		WithMiddleware(
+			func(next http.RoundTripper) http.RoundTripper { return record("outer", next) },
+			func(next http.RoundTripper) http.RoundTripper { return record("inner", next) },
+		),
+	)
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.GetDeviceState(ctx, 1); err != nil {
+		t.Fatalf("GetDeviceState: %v", err)
+	}
+
+	// Middlewares видят один запрос без токена; повтор после 401 и повтор после 503
+	// выполняются внутри цепочки и доходят до базового транспорта с новым токеном
+	want := []string{
+		`outer ""`,
+		`inner ""`,
+		`base "Bearer token-1"`,
+		`base "Bearer token-2"`,
+		`base "Bearer token-2"`,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+
+	// Circuit Breaker находится под повторами и учитывает каждую попытку отдельно
+	if health := c.BreakerHealth(); health.Failures != 1 || health.ConsecutiveFailures != 0 {
+		t.Errorf("health = %+v, want one failure from the 503 attempt", health)
+	}
+}
@@ -1,9 +1,11 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 )
@@ -40,6 +42,8 @@ type Logger struct {
 	level  LogLevel
 	mu     sync.Mutex
 	output io.Writer
+
+	reportedEnums sync.Map // Неизвестные значения API, о которых уже сообщено
 }
 
 // NewLogger — создает новый логгер
@@ -106,3 +110,77 @@ func (l *Logger) Warn(format string, args ...interface{}) {
 func (l *Logger) Error(format string, args ...interface{}) {
 	l.log(LogError, format, args...)
 }
+
+// sensitiveKeys — поля JSON, значения которых не должны попадать в логи
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+}
+
+// redactSecret — скрывает секрет, оставляя только его длину
+func redactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return fmt.Sprintf("[REDACTED %d chars]", len(secret))
+}
+
+// maxLoggedText — предел длины текста ответа не в JSON в ошибках и логах
+const maxLoggedText = 200
+
+// redactText — текст ответа не в JSON для ошибок и логов: обрезается,
+// а если упоминает секретное поле — скрывается целиком
+func redactText(text string) string {
+	lower := strings.ToLower(text)
+	for key := range sensitiveKeys {
+		if strings.Contains(lower, key) {
+			return "<non-JSON response omitted>"
+		}
+	}
+	if runes := []rune(text); len(runes) > maxLoggedText {
+		return string(runes[:maxLoggedText]) + "…"
+	}
+	return text
+}
+
+// redactJSON — заменяет значения секретных полей JSON для логирования
+func redactJSON(data []byte) []byte {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []byte("<non-JSON response omitted>")
+	}
+
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return []byte("<response omitted>")
+	}
+	return redacted
+}
+
+// redactValue — рекурсивно скрывает секретные поля; значение секретного поля
+// скрывается целиком, каким бы ни был его тип
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if !sensitiveKeys[strings.ToLower(key)] {
+				v[key] = redactValue(item)
+				continue
+			}
+			switch secret := item.(type) {
+			case nil:
+			case string:
+				v[key] = redactSecret(secret)
+			default:
+				v[key] = "[REDACTED]"
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testPassword     = "s3cr3t-Passw0rd"
+	testAccessToken  = "SECRET-ACCESS-TOKEN-0123456789"
+	testMQTTPassword = "SECRET-MQTT-PASSWORD"
+)
+
+// newLeakyServer — сервер, который возвращает секреты во всех ответах, включая ошибки
+func newLeakyServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	leak := fmt.Sprintf(`{"done":false,"errors":"boom","token":%q,"password":%q}`, testAccessToken, testPassword)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasSuffix(path, "/token") {
+			if err := r.ParseForm(); err != nil || r.PostForm.Get("password") != testPassword {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"done":true,"data":{"access_token":%q}}`, testAccessToken)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+testAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case strings.HasSuffix(path, "/user"):
+			fmt.Fprintf(w, `{"done":true,"data":{"id":1,"token":%q,"mqttUser":{"username":"mq","password":%q}}}`,
+				testAccessToken, testMQTTPassword)
+		case strings.HasSuffix(path, "/buildings"):
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, leak)
+		case strings.HasSuffix(path, "/functions"):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, leak)
+		case strings.HasSuffix(path, "/ctrl"):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, leak)
+		case strings.HasSuffix(path, "/devices/2"):
+			fmt.Fprintf(w, "<html>token=%s password=%s</html>", testAccessToken, testPassword)
+		default:
+			fmt.Fprintf(w, `{"done":true,"data":{"id":1,"status":"connected"},"token":%q}`, testAccessToken)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLogsNeverContainSecrets(t *testing.T) {
+	srv := newLeakyServer(t)
+	ctx := context.Background()
+
+	var logs bytes.Buffer
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", testPassword,
+		WithBaseURL(srv.URL), WithLogger(NewLogger(LogDebug, &logs)))
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	// Ошибки ответов ожидаемы: проверяется только содержимое логов
+	_, _ = c.GetMqttUserInfo(ctx)
+	_, _ = c.GetBuildings(ctx)
+	_, _ = c.GetDeviceState(ctx, 1)
+	_, _ = c.GetDeviceState(ctx, 2)
+	_, _ = c.GetDeviceFunctions(ctx, 1)
+	on := true
+	_, _ = c.ControlDevice(ctx, 1, DeviceControlRequest{Value: DeviceFunctionControl{FunctionID: 1, IsOn: &on}})
+
+	if logs.Len() == 0 {
+		t.Fatal("expected debug output")
+	}
+	for _, secret := range []string{testPassword, testAccessToken, testMQTTPassword} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("secret %q found in logs:\n%s", secret, logs.String())
+		}
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	got := string(redactJSON([]byte(`{"data":{"access_token":"abc","items":[{"Password":"p"}],"title":"ok"}}`)))
+	for _, secret := range []string{`"abc"`, `"p"`} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redactJSON left %s in %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"title":"ok"`) {
+		t.Errorf("redactJSON dropped non-secret field: %s", got)
+	}
+	if got := string(redactJSON([]byte("token=abc"))); strings.Contains(got, "abc") {
+		t.Errorf("non-JSON body logged: %s", got)
+	}
+}
+
+func TestRedactJSONNonStringSecrets(t *testing.T) {
+	body := `{"token":123456789,"password":{"value":"nested-secret"},"refresh_token":["list-secret"],"access_token":null}`
+	got := string(redactJSON([]byte(body)))
+	for _, secret := range []string{"123456789", "nested-secret", "list-secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redactJSON left %s in %s", secret, got)
+		}
+	}
+	if !strings.Contains(got, `"access_token":null`) {
+		t.Errorf("redactJSON changed a null secret: %s", got)
+	}
+}
+
+func TestAPIErrorsNeverContainSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/token") && r.FormValue("email") == "bad@example.com":
+			fmt.Fprintf(w, `{"done":false,"errors":{"password":%q}}`, testPassword)
+		case strings.HasSuffix(path, "/token"):
+			fmt.Fprintf(w, `{"done":true,"data":{"access_token":%q}}`, testAccessToken)
+		case strings.HasSuffix(path, "/buildings"):
+			fmt.Fprintf(w, `{"done":false,"errors":[{"message":"denied","token":%q},{"token":%q}]}`, testAccessToken, testAccessToken)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(w, "upstream failed: token=%s", testAccessToken)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	var logs bytes.Buffer
+	logger := NewLogger(LogDebug, &logs)
+	var errs []error
+
+	_, err := NewAuthorizedDaichiClient(ctx, "bad@example.com", "password", WithBaseURL(srv.URL), WithLogger(logger))
+	errs = append(errs, err)
+
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+	_, err = c.GetBuildings(ctx)
+	errs = append(errs, err)
+	_, err = c.GetDeviceState(ctx, 1)
+	errs = append(errs, err)
+
+	for _, err := range errs {
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		for _, secret := range []string{testPassword, testAccessToken} {
+			if strings.Contains(err.Error(), secret) {
+				t.Errorf("secret %q found in error: %v", secret, err)
+			}
+		}
+	}
+	for _, secret := range []string{testPassword, testAccessToken} {
+		if strings.Contains(logs.String(), secret) {
+			t.Errorf("secret %q found in logs:\n%s", secret, logs.String())
+		}
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	if got := redactText("Bad Gateway"); got != "Bad Gateway" {
+		t.Errorf("redactText changed a plain message: %q", got)
+	}
+	if got := redactText("password=hunter2"); strings.Contains(got, "hunter2") {
+		t.Errorf("redactText left a secret: %q", got)
+	}
+	if got := []rune(redactText(strings.Repeat("ы", 500))); len(got) != maxLoggedText+1 {
+		t.Errorf("redactText length = %d, want %d", len(got), maxLoggedText+1)
+	}
+}
+
+func TestMQTTUserHidesPassword(t *testing.T) {
+	user := MQTTUser{Username: "mq", Password: testMQTTPassword}
+	for _, out := range []string{
+		fmt.Sprintf("%v %+v %s", user, user, &user),
+		user.RedactedPassword(),
+	} {
+		if strings.Contains(out, testMQTTPassword) {
+			t.Errorf("MQTT password printed: %s", out)
+		}
+	}
+	if !strings.Contains(user.String(), "mq") {
+		t.Errorf("String() = %q, want the username", user.String())
+	}
+}
@@ -0,0 +1,194 @@
+package mqtt
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+// fakeBroker — брокер в памяти: доставляет публикации подписчикам по фильтрам топиков
+type fakeBroker struct {
+	mu         sync.Mutex
+	subs       map[string]paho.MessageHandler
+	published  []fakeMessage
+	publishErr error // Ошибка, которую вернет Publish
+	stall      bool  // Publish никогда не завершается
+	stallSubs  bool  // Subscribe никогда не завершается
+
+	// onPublish — реакция «устройства» на команду
+	onPublish func(b *fakeBroker, topic string, payload []byte)
+
+	conn *fakeClient
+}
+
+func newFakeBroker() *fakeBroker {
+	return &fakeBroker{subs: make(map[string]paho.MessageHandler)}
+}
+
+// deliver — отправляет сообщение всем подписчикам подходящих фильтров
+func (b *fakeBroker) deliver(topic string, payload []byte) {
+	b.mu.Lock()
+	var handlers []paho.MessageHandler
+	for filter, handler := range b.subs {
+		if topicMatches(filter, topic) {
+			handlers = append(handlers, handler)
+		}
+	}
+	conn := b.conn
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(conn, fakeMessage{topic: topic, payload: payload})
+	}
+}
+
+// messages — опубликованные сообщения
+func (b *fakeBroker) messages() []fakeMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]fakeMessage(nil), b.published...)
+}
+
+// fakeClient — paho.Client, подключенный к fakeBroker.
+// Встроенный интерфейс покрывает методы, которые Subscriber не вызывает.
+type fakeClient struct {
+	paho.Client
+	broker    *fakeBroker
+	onConnect paho.OnConnectHandler
+
+	mu        sync.Mutex
+	connected bool
+}
+
+// Connect, как и paho, вызывает OnConnect в отдельной горутине
+func (c *fakeClient) Connect() paho.Token {
+	c.mu.Lock()
+	c.connected = true
+	c.mu.Unlock()
+	if c.onConnect != nil {
+		go c.onConnect(c)
+	}
+	return doneToken(nil)
+}
+
+// reconnect — имитирует автоматическое переподключение paho
+func (c *fakeClient) reconnect() {
+	if c.onConnect != nil {
+		go c.onConnect(c)
+	}
+}
+
+func (c *fakeClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+func (c *fakeClient) Disconnect(uint) {
+	c.mu.Lock()
+	c.connected = false
+	c.mu.Unlock()
+}
+
+func (c *fakeClient) Subscribe(topic string, _ byte, callback paho.MessageHandler) paho.Token {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+	if c.broker.stallSubs {
+		return &fakeToken{done: make(chan struct{})}
+	}
+	c.broker.subs[topic] = callback
+	return doneToken(nil)
+}
+
+func (c *fakeClient) Publish(topic string, _ byte, _ bool, payload interface{}) paho.Token {
+	data, _ := payload.([]byte)
+	b := c.broker
+
+	b.mu.Lock()
+	b.published = append(b.published, fakeMessage{topic: topic, payload: data})
+	err, stall, onPublish := b.publishErr, b.stall, b.onPublish
+	b.mu.Unlock()
+
+	if stall {
+		return &fakeToken{done: make(chan struct{})}
+	}
+	if err == nil && onPublish != nil {
+		go onPublish(b, topic, data)
+	}
+	return doneToken(err)
+}
+
+// fakeToken — завершенная (или зависшая) операция paho
+type fakeToken struct {
+	paho.Token
+	done chan struct{}
+	err  error
+}
+
+func doneToken(err error) *fakeToken {
+	t := &fakeToken{done: make(chan struct{}), err: err}
+	close(t.done)
+	return t
+}
+
+func (t *fakeToken) Wait() bool {
+	<-t.done
+	return true
+}
+
+func (t *fakeToken) WaitTimeout(timeout time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (t *fakeToken) Done() <-chan struct{} { return t.done }
+func (t *fakeToken) Error() error          { return t.err }
+
+// fakeMessage — сообщение брокера
+type fakeMessage struct {
+	paho.Message
+	topic   string
+	payload []byte
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return m.payload }
+
+// connectFake — создает подписку на устройство 7, подключенную к fakeBroker
+func connectFake(t *testing.T, broker *fakeBroker, opts ...Option) *Subscriber {
+	t.Helper()
+	s := newFakeSubscriber(t, broker, append([]Option{WithDeviceIDs(7)}, opts...)...)
+	if err := s.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	return s
+}
+
+// newFakeSubscriber — создает подписку, которая подключается к fakeBroker
+func newFakeSubscriber(t *testing.T, broker *fakeBroker, opts ...Option) *Subscriber {
+	t.Helper()
+	opts = append([]Option{WithLogger(client.NewLogger(client.LogDebug, io.Discard))}, opts...)
+	s, err := NewSubscriber(&client.MQTTUser{Username: "mq", Password: "secret"}, opts...)
+	if err != nil {
+		t.Fatalf("NewSubscriber: %v", err)
+	}
+	s.newClient = func(opts *paho.ClientOptions) paho.Client {
+		conn := &fakeClient{broker: broker, onConnect: opts.OnConnect}
+		broker.mu.Lock()
+		broker.conn = conn
+		broker.mu.Unlock()
+		return conn
+	}
+	t.Cleanup(s.Close)
+	return s
+}
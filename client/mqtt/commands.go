@@ -0,0 +1,147 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+// Константы команд
+const (
+	DefaultCommandTopic         = "devices/%d/ctrl"
+	DefaultCommandResponseTopic = "devices/+/ctrl/response"
+	DefaultCommandTimeout       = 5 * time.Second
+)
+
+// WithCommandTopics — устанавливает топик команд (формат с %d для ID устройства) и топик ответов
+func WithCommandTopics(commandTopic, responseTopic string) Option {
+	return func(s *Subscriber) {
+		s.commandTopic = commandTopic
+		s.responseTopic = responseTopic
+	}
+}
+
+// WithCommandTimeout — устанавливает время ожидания ответа на команду
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(s *Subscriber) {
+		s.commandTimeout = timeout
+	}
+}
+
+// PublishCommand — отправляет команду через брокер и ждет ответа.
+// Реализует client.CommandPublisher: если команда не отправлена, ошибка оборачивает client.ErrTransportUnavailable,
+// если отправлена, но ответа нет — client.ErrCommandTimeout (HTTP-фолбэк в этом случае не выполняется).
+func (s *Subscriber) PublishCommand(ctx context.Context, deviceID int, control client.DeviceControlRequest) (*client.DaichiBuildingDeviceStruct, error) {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	if conn == nil || !conn.IsConnected() {
+		return nil, fmt.Errorf("%w: MQTT session is not connected", client.ErrTransportUnavailable)
+	}
+
+	payload, err := json.Marshal(control)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode device control request: %w", err)
+	}
+
+	// Ответы сопоставляются по cmdId, поэтому две команды с одним ID не могут ждать одновременно
+	response := make(chan []byte, 1)
+	s.pendingMutex.Lock()
+	if _, busy := s.pending[control.CmdID]; busy {
+		s.pendingMutex.Unlock()
+		s.Logger.Error("MQTT command %d is already waiting for a response", control.CmdID)
+		return nil, &client.ValidationError{Field: "cmdId", Value: control.CmdID, Reason: "another command with this ID is in flight"}
+	}
+	s.pending[control.CmdID] = response
+	s.pendingMutex.Unlock()
+	defer func() {
+		s.pendingMutex.Lock()
+		if s.pending[control.CmdID] == response {
+			delete(s.pending, control.CmdID)
+		}
+		s.pendingMutex.Unlock()
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, s.commandTimeout)
+	defer cancel()
+
+	topic := fmt.Sprintf(s.commandTopic, deviceID)
+	s.Logger.Debug("Publishing command %d to %s", control.CmdID, topic)
+	if err := waitToken(waitCtx, conn.Publish(topic, s.qos, false, payload)); err != nil {
+		if waitCtx.Err() != nil {
+			return nil, s.timeoutError(ctx, "publish was not acknowledged")
+		}
+		return nil, s.transportError(ctx, "publish failed", err)
+	}
+
+	select {
+	case body := <-response:
+		return client.ParseControlResponse(s.Logger, deviceID, control, http.StatusOK, body)
+	case <-waitCtx.Done():
+		return nil, s.timeoutError(ctx, "got no response")
+	}
+}
+
+// timeoutError — команда могла дойти до устройства, поэтому повторять ее по HTTP нельзя
+func (s *Subscriber) timeoutError(ctx context.Context, msg string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.Logger.Warn("MQTT command %s within %v", msg, s.commandTimeout)
+	return fmt.Errorf("%w: %s within %v", client.ErrCommandTimeout, msg, s.commandTimeout)
+}
+
+// transportError — различает отмену вызывающим и недоступность транспорта
+func (s *Subscriber) transportError(ctx context.Context, msg string, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	s.Logger.Warn("MQTT command %s: %v", msg, err)
+	return fmt.Errorf("%w: %s: %v", client.ErrTransportUnavailable, msg, err)
+}
+
+// handleCommandResponse — передает ответ ожидающей команде по cmdId
+func (s *Subscriber) handleCommandResponse(_ paho.Client, msg paho.Message) {
+	var envelope struct {
+		CmdID int `json:"cmdId"`
+	}
+	if err := json.Unmarshal(msg.Payload(), &envelope); err != nil {
+		s.Logger.Warn("Failed to decode MQTT command response on %s: %v", msg.Topic(), err)
+		return
+	}
+
+	s.pendingMutex.Lock()
+	response, ok := s.pending[envelope.CmdID]
+	s.pendingMutex.Unlock()
+	if !ok {
+		s.Logger.Debug("Ignoring MQTT response for unknown command %d", envelope.CmdID)
+		return
+	}
+
+	select {
+	case response <- msg.Payload():
+	default:
+	}
+}
+
+// topicMatches — проверяет соответствие топика фильтру MQTT с + и #
+func topicMatches(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+	for i, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+		if i >= len(topicParts) || (part != "+" && part != topicParts[i]) {
+			return false
+		}
+	}
+	return len(filterParts) == len(topicParts)
+}
@@ -0,0 +1,214 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+// replyToCommands — «устройство», которое отвечает на каждую команду ее cmdId
+func replyToCommands(b *fakeBroker, topic string, payload []byte) {
+	var command client.DeviceControlRequest
+	if err := json.Unmarshal(payload, &command); err != nil {
+		return
+	}
+	var deviceID int
+	if _, err := fmt.Sscanf(topic, "devices/%d/ctrl", &deviceID); err != nil {
+		return
+	}
+
+	responseTopic := fmt.Sprintf("devices/%d/ctrl/response", deviceID)
+	// Ответ на чужую команду не должен попасть к отправителю
+	b.deliver(responseTopic, []byte(fmt.Sprintf(`{"cmdId":%d,"done":true,"data":{"id":%d}}`, command.CmdID+1, deviceID)))
+	b.deliver(responseTopic, []byte(fmt.Sprintf(`{"cmdId":%d,"done":true,"data":{"id":%d,"state":{"isOn":%t}}}`,
+		command.CmdID, deviceID, *command.Value.IsOn)))
+}
+
+func powerCommand(cmdID int, on bool) client.DeviceControlRequest {
+	return client.DeviceControlRequest{CmdID: cmdID, Value: client.DeviceFunctionControl{FunctionID: 350, IsOn: &on}}
+}
+
+func TestPublishCommandReturnsMatchingResponse(t *testing.T) {
+	broker := newFakeBroker()
+	broker.onPublish = replyToCommands
+	s := connectFake(t, broker)
+
+	state, err := s.PublishCommand(context.Background(), 7, powerCommand(42, true))
+	if err != nil {
+		t.Fatalf("PublishCommand: %v", err)
+	}
+	if state.ID != 7 || !state.State.IsOn {
+		t.Errorf("state = %+v, want device 7 turned on", state)
+	}
+
+	published := broker.messages()
+	if len(published) != 1 || published[0].topic != "devices/7/ctrl" {
+		t.Fatalf("published = %+v, want one command on devices/7/ctrl", published)
+	}
+	var sent client.DeviceControlRequest
+	if err := json.Unmarshal(published[0].payload, &sent); err != nil || sent.CmdID != 42 {
+		t.Errorf("published payload = %s", published[0].payload)
+	}
+}
+
+func TestPublishCommandNotConnected(t *testing.T) {
+	s := connectFake(t, newFakeBroker())
+	s.Close()
+
+	_, err := s.PublishCommand(context.Background(), 7, powerCommand(1, true))
+	if !errors.Is(err, client.ErrTransportUnavailable) {
+		t.Fatalf("err = %v, want ErrTransportUnavailable", err)
+	}
+}
+
+func TestPublishCommandPublishFailure(t *testing.T) {
+	broker := newFakeBroker()
+	broker.publishErr = errors.New("broker rejected publish")
+	s := connectFake(t, broker)
+
+	_, err := s.PublishCommand(context.Background(), 7, powerCommand(1, true))
+	if !errors.Is(err, client.ErrTransportUnavailable) {
+		t.Fatalf("err = %v, want ErrTransportUnavailable", err)
+	}
+}
+
+func TestPublishCommandRejectsDuplicateCmdID(t *testing.T) {
+	broker := newFakeBroker()
+	released := make(chan struct{})
+	broker.onPublish = func(b *fakeBroker, topic string, payload []byte) {
+		// Первая команда получает ответ только после попытки отправить вторую
+		if len(b.messages()) == 1 {
+			<-released
+		}
+		replyToCommands(b, topic, payload)
+	}
+	s := connectFake(t, broker)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.PublishCommand(context.Background(), 7, powerCommand(5, true))
+		first <- err
+	}()
+	waitPending(t, s, 5)
+
+	_, err := s.PublishCommand(context.Background(), 7, powerCommand(5, false))
+	if !errors.Is(err, client.ErrInvalidValue) {
+		t.Errorf("duplicate cmdId err = %v, want ErrInvalidValue", err)
+	}
+
+	close(released)
+	if err := <-first; err != nil {
+		t.Fatalf("first command: %v", err)
+	}
+	if got := len(broker.messages()); got != 1 {
+		t.Errorf("published %d commands, want 1", got)
+	}
+
+	// После ответа ID снова свободен
+	if _, err := s.PublishCommand(context.Background(), 7, powerCommand(5, false)); err != nil {
+		t.Errorf("reused cmdId after completion: %v", err)
+	}
+}
+
+// waitPending — ждет, пока команда начнет ожидать ответ
+func waitPending(t *testing.T, s *Subscriber, cmdID int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.pendingMutex.Lock()
+		_, ok := s.pending[cmdID]
+		s.pendingMutex.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("command %d is not pending", cmdID)
+}
+
+// countingAPI — HTTP API, которое считает команды управления
+func countingAPI(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var commands atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/token"):
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+		case strings.HasSuffix(r.URL.Path, "/ctrl"):
+			commands.Add(1)
+			fmt.Fprint(w, `{"done":true,"data":{"id":7,"state":{"isOn":true}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &commands
+}
+
+func TestAutoTransportFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(b *fakeBroker, s *Subscriber)
+		wantErr      error
+		wantHTTPCall int32
+	}{
+		{
+			name:    "no response after publish",
+			setup:   func(*fakeBroker, *Subscriber) {},
+			wantErr: client.ErrCommandTimeout,
+		},
+		{
+			name:    "publish not acknowledged",
+			setup:   func(b *fakeBroker, _ *Subscriber) { b.stall = true },
+			wantErr: client.ErrCommandTimeout,
+		},
+		{
+			name:         "publish failed",
+			setup:        func(b *fakeBroker, _ *Subscriber) { b.publishErr = errors.New("broker rejected publish") },
+			wantHTTPCall: 1,
+		},
+		{
+			name:         "session not connected",
+			setup:        func(_ *fakeBroker, s *Subscriber) { s.Close() },
+			wantHTTPCall: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, commands := countingAPI(t)
+			broker := newFakeBroker()
+			s := connectFake(t, broker, WithCommandTimeout(50*time.Millisecond))
+			tt.setup(broker, s)
+
+			ctx := context.Background()
+			c, err := client.NewAuthorizedDaichiClient(ctx, "user@example.com", "password",
+				client.WithBaseURL(srv.URL), client.WithNoLogs(),
+				client.WithTransport(client.TransportAuto), client.WithCommandPublisher(s))
+			if err != nil {
+				t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+			}
+			defer c.Close()
+
+			_, err = c.ControlDevice(ctx, 7, powerCommand(0, true))
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("err = %v, want HTTP fallback to succeed", err)
+			}
+			if got := commands.Load(); got != tt.wantHTTPCall {
+				t.Errorf("HTTP control requests = %d, want %d", got, tt.wantHTTPCall)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+// EventType — тип события устройства
+type EventType string
+
+const (
+	EventStateChanged       EventType = "state"
+	EventOnline             EventType = "online"
+	EventOffline            EventType = "offline"
+	EventTemperatureUpdated EventType = "temperature"
+	EventUnknown            EventType = "unknown"
+)
+
+// DeviceEvent — событие, полученное от брокера
+type DeviceEvent struct {
+	Type        EventType
+	DeviceID    int
+	Serial      string
+	Topic       string
+	ReceivedAt  time.Time
+	State       *client.DaichiBuildingDeviceStruct // Для EventStateChanged
+	Temperature float64                            // Для EventTemperatureUpdated
+	Raw         json.RawMessage                    // Исходное сообщение
+}
+
+// eventPayload — формат сообщения брокера
+type eventPayload struct {
+	Type     string          `json:"type"`
+	DeviceID int             `json:"deviceId"`
+	Serial   string          `json:"serial"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// decodeEvent — преобразует сообщение брокера в DeviceEvent
+func decodeEvent(topic string, payload []byte) (DeviceEvent, error) {
+	event := DeviceEvent{
+		Type:       EventUnknown,
+		Topic:      topic,
+		ReceivedAt: time.Now(),
+		Raw:        json.RawMessage(payload),
+	}
+
+	var msg eventPayload
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return event, fmt.Errorf("invalid event payload: %w", err)
+	}
+	event.DeviceID = msg.DeviceID
+	event.Serial = msg.Serial
+
+	switch EventType(msg.Type) {
+	case EventStateChanged:
+		var state client.DaichiBuildingDeviceStruct
+		if err := json.Unmarshal(msg.Data, &state); err != nil {
+			return event, fmt.Errorf("invalid state payload: %w", err)
+		}
+		if state.ID == 0 {
+			state.ID = msg.DeviceID
+		}
+		event.Type = EventStateChanged
+		event.State = &state
+
+	case EventTemperatureUpdated:
+		var data struct {
+			CurTemp float64 `json:"curTemp"`
+		}
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return event, fmt.Errorf("invalid temperature payload: %w", err)
+		}
+		event.Type = EventTemperatureUpdated
+		event.Temperature = data.CurTemp
+
+	case EventOnline, EventOffline:
+		event.Type = EventType(msg.Type)
+	}
+
+	return event, nil
+}
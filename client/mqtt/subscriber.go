@@ -0,0 +1,462 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+// Константы
+const (
+	DefaultBrokerURL   = "ssl://mqtt.daichicloud.ru:8883"
+	DefaultDeviceTopic = "devices/%d/#"
+	DefaultBufferSize  = 64
+	DefaultQoS         = 1
+	connectTimeout     = 10 * time.Second
+	disconnectQuiesce  = 250 // мс
+)
+
+// Sentinel ошибки
+var (
+	ErrMissingMQTTUser  = errors.New("MQTT credentials are not available")
+	ErrClosed           = errors.New("MQTT subscriber is closed")
+	ErrNoTopics         = errors.New("no MQTT topics to subscribe: use WithDeviceIDs or WithTopics")
+	ErrSubscribeTimeout = errors.New("MQTT subscribe was not acknowledged in time")
+)
+
+// Subscriber — подписка на события устройств через брокер Daichi
+type Subscriber struct {
+	brokerURL        string
+	clientID         string
+	topics           []string
+	subscribeTimeout time.Duration
+	qos              byte
+	bufferSize       int
+	Logger           *client.Logger
+
+	handlers      []eventHandler
+	nextHandlerID int
+	streams       map[*stateStream]struct{} // Каналы DeviceStates, закрываются в Close
+
+	commandTopic   string
+	responseTopic  string
+	commandTimeout time.Duration
+	pending        map[int]chan []byte
+	pendingMutex   sync.Mutex
+
+	user      client.MQTTUser
+	conn      paho.Client
+	newClient func(*paho.ClientOptions) paho.Client // Фабрика MQTT-клиента (подменяется в тестах)
+	events    chan DeviceEvent
+	closed    bool
+	mu        sync.Mutex
+}
+
+// eventHandler — callback события с идентификатором для отписки
+type eventHandler struct {
+	id int
+	fn func(DeviceEvent)
+}
+
+// Option — функциональный тип для настройки подписки
+type Option func(*Subscriber)
+
+// WithBrokerURL — устанавливает адрес брокера
+func WithBrokerURL(url string) Option {
+	return func(s *Subscriber) {
+		s.brokerURL = url
+	}
+}
+
+// WithClientID — устанавливает MQTT client ID
+func WithClientID(id string) Option {
+	return func(s *Subscriber) {
+		s.clientID = id
+	}
+}
+
+// WithTopics — устанавливает топики для подписки
+func WithTopics(topics ...string) Option {
+	return func(s *Subscriber) {
+		s.topics = topics
+	}
+}
+
+// WithDeviceIDs — подписывается на топики указанных устройств (DefaultDeviceTopic)
+func WithDeviceIDs(ids ...int) Option {
+	return func(s *Subscriber) {
+		for _, id := range ids {
+			s.topics = append(s.topics, fmt.Sprintf(DefaultDeviceTopic, id))
+		}
+	}
+}
+
+// WithQoS — устанавливает уровень QoS подписки
+func WithQoS(qos byte) Option {
+	return func(s *Subscriber) {
+		s.qos = qos
+	}
+}
+
+// WithBufferSize — устанавливает размер буфера канала событий
+func WithBufferSize(size int) Option {
+	return func(s *Subscriber) {
+		s.bufferSize = size
+	}
+}
+
+// WithEventHandler — добавляет callback для каждого события
+func WithEventHandler(handler func(DeviceEvent)) Option {
+	return func(s *Subscriber) {
+		s.addHandler(handler)
+	}
+}
+
+// WithLogger — устанавливает логгер
+func WithLogger(logger *client.Logger) Option {
+	return func(s *Subscriber) {
+		if logger == nil {
+			logger = client.NewLogger(client.LogInfo, os.Stderr)
+		}
+		s.Logger = logger
+	}
+}
+
+// NewSubscriber — создает подписку с MQTT-данными пользователя
+func NewSubscriber(user *client.MQTTUser, opts ...Option) (*Subscriber, error) {
+	if user == nil || user.Username == "" {
+		return nil, ErrMissingMQTTUser
+	}
+
+	s := &Subscriber{
+		brokerURL:        DefaultBrokerURL,
+		clientID:         fmt.Sprintf("daichi-ac-sdk-%d", time.Now().UnixNano()),
+		subscribeTimeout: connectTimeout,
+		qos:              DefaultQoS,
+		bufferSize:       DefaultBufferSize,
+		Logger:           client.NewLogger(client.LogInfo, os.Stderr),
+		user:             *user,
+		newClient:        paho.NewClient,
+
+		commandTopic:   DefaultCommandTopic,
+		responseTopic:  DefaultCommandResponseTopic,
+		commandTimeout: DefaultCommandTimeout,
+		pending:        make(map[int]chan []byte),
+		streams:        make(map[*stateStream]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.events = make(chan DeviceEvent, s.bufferSize)
+	return s, nil
+}
+
+// Dial — получает MQTT-данные через API и подключается к брокеру
+func Dial(ctx context.Context, c *client.AuthorizedDaichiClient, opts ...Option) (*Subscriber, error) {
+	userInfo, err := c.GetMqttUserInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := NewSubscriber(userInfo.MQTTUser, append([]Option{WithLogger(c.Logger)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без явных топиков подписываемся только на устройства пользователя
+	if len(s.topics) == 0 {
+		ids, err := deviceIDs(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		WithDeviceIDs(ids...)(s)
+	}
+
+	if err := s.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// deviceIDs — идентификаторы всех устройств пользователя
+func deviceIDs(ctx context.Context, c *client.AuthorizedDaichiClient) ([]int, error) {
+	buildings, err := c.GetBuildings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, building := range buildings {
+		for _, device := range building.Places {
+			ids = append(ids, device.ID)
+		}
+	}
+	return ids, nil
+}
+
+// Connect — подключается к брокеру и подписывается на топики.
+// Ошибка или тайм-аут любой подписки возвращается, а соединение закрывается.
+func (s *Subscriber) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if len(s.topics) == 0 {
+		s.Logger.Error("MQTT connect failed: %v", ErrNoTopics)
+		return ErrNoTopics
+	}
+
+	// Первое подключение подписывается синхронно ниже, OnConnect paho вызывает в отдельной горутине
+	var reconnected atomic.Bool
+	opts := paho.NewClientOptions().
+		AddBroker(s.brokerURL).
+		SetClientID(s.clientID).
+		SetUsername(s.user.Username).
+		SetPassword(s.user.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(connectTimeout).
+		SetOnConnectHandler(s.onConnect(&reconnected)).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			s.Logger.Warn("MQTT connection lost: %v", err)
+		})
+
+	conn := s.newClient(opts)
+
+	s.Logger.Info("Connecting to MQTT broker %s...", s.brokerURL)
+	if err := waitToken(ctx, conn.Connect()); err != nil {
+		s.Logger.Error("MQTT connect failed: %v", err)
+		return fmt.Errorf("mqtt connect failed: %w", err)
+	}
+	if err := s.subscribeAll(conn); err != nil {
+		conn.Disconnect(disconnectQuiesce)
+		return err
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	return nil
+}
+
+// onConnect — после переподключения заново подписывается на топики;
+// первое подключение пропускается, его подписки выполняет Connect
+func (s *Subscriber) onConnect(reconnected *atomic.Bool) paho.OnConnectHandler {
+	return func(conn paho.Client) {
+		if !reconnected.Swap(true) {
+			return
+		}
+		s.Logger.Info("Reconnected to MQTT broker, resubscribing...")
+		_ = s.subscribeAll(conn)
+	}
+}
+
+// subscribeAll — подписывается на топики событий и ответов на команды; возвращает первую ошибку
+func (s *Subscriber) subscribeAll(conn paho.Client) error {
+	for _, topic := range s.topics {
+		if err := s.subscribe(conn, topic, s.handleMessage); err != nil {
+			return err
+		}
+	}
+	if s.responseTopic != "" {
+		return s.subscribe(conn, s.responseTopic, s.handleCommandResponse)
+	}
+	return nil
+}
+
+// subscribe — подписывается на топик; не дождавшаяся подтверждения подписка считается неудачной
+func (s *Subscriber) subscribe(conn paho.Client, topic string, handler paho.MessageHandler) error {
+	token := conn.Subscribe(topic, s.qos, handler)
+	if !token.WaitTimeout(s.subscribeTimeout) {
+		s.Logger.Error("MQTT subscribe to %s timed out after %v", topic, s.subscribeTimeout)
+		return fmt.Errorf("%w: %s after %v", ErrSubscribeTimeout, topic, s.subscribeTimeout)
+	}
+	if err := token.Error(); err != nil {
+		s.Logger.Error("MQTT subscribe to %s failed: %v", topic, err)
+		return fmt.Errorf("mqtt subscribe to %s failed: %w", topic, err)
+	}
+	s.Logger.Info("Subscribed to MQTT topic %s", topic)
+	return nil
+}
+
+// handleMessage — декодирует сообщение и доставляет событие
+func (s *Subscriber) handleMessage(_ paho.Client, msg paho.Message) {
+	// Ответы на команды обрабатывает handleCommandResponse, собственные команды пропускаем
+	if s.responseTopic != "" && topicMatches(s.responseTopic, msg.Topic()) {
+		return
+	}
+	if topicMatches(strings.ReplaceAll(s.commandTopic, "%d", "+"), msg.Topic()) {
+		return
+	}
+
+	event, err := decodeEvent(msg.Topic(), msg.Payload())
+	if err != nil {
+		s.Logger.Warn("Failed to decode MQTT message on %s: %v", msg.Topic(), err)
+		return
+	}
+	s.Logger.Debug("MQTT event %s for device %d", event.Type, event.DeviceID)
+	if event.State != nil {
+		s.Logger.WarnUnknownEnums(event.State.EnumValues()...)
+	}
+
+	s.mu.Lock()
+	handlers := make([]func(DeviceEvent), 0, len(s.handlers))
+	for _, h := range s.handlers {
+		handlers = append(handlers, h.fn)
+	}
+	s.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		s.Logger.Warn("MQTT event buffer full, dropping %s event for device %d", event.Type, event.DeviceID)
+	}
+}
+
+// Events — канал событий устройств; закрывается в Close
+func (s *Subscriber) Events() <-chan DeviceEvent {
+	return s.events
+}
+
+// stateStream — канал DeviceStates, который можно безопасно закрыть во время доставки
+type stateStream struct {
+	mu     sync.Mutex
+	ch     chan *client.DaichiBuildingDeviceStruct
+	closed bool
+}
+
+// send — отправляет состояние без блокировки; лишние состояния при полном буфере отбрасываются
+func (st *stateStream) send(state *client.DaichiBuildingDeviceStruct) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.closed {
+		return
+	}
+	select {
+	case st.ch <- state:
+	default:
+	}
+}
+
+// close — закрывает канал один раз
+func (st *stateStream) close() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.closed {
+		st.closed = true
+		close(st.ch)
+	}
+}
+
+// DeviceStates — канал обновлений состояния, совместимый с client.WaitOptions.Updates.
+// Канал закрывается функцией отписки или в Close.
+func (s *Subscriber) DeviceStates() (<-chan *client.DaichiBuildingDeviceStruct, func()) {
+	stream := &stateStream{ch: make(chan *client.DaichiBuildingDeviceStruct, s.bufferSize)}
+	removeHandler := s.OnEvent(func(event DeviceEvent) {
+		if event.Type == EventStateChanged {
+			stream.send(event.State)
+		}
+	})
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		removeHandler()
+		stream.close()
+		return stream.ch, func() {}
+	}
+	s.streams[stream] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return stream.ch, func() {
+		once.Do(func() {
+			removeHandler()
+			s.mu.Lock()
+			delete(s.streams, stream)
+			s.mu.Unlock()
+			stream.close()
+		})
+	}
+}
+
+// OnEvent — добавляет callback после создания подписки и возвращает функцию отписки
+func (s *Subscriber) OnEvent(handler func(DeviceEvent)) func() {
+	return s.addHandler(handler)
+}
+
+// addHandler — добавляет callback и возвращает функцию его удаления
+func (s *Subscriber) addHandler(fn func(DeviceEvent)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextHandlerID++
+	id := s.nextHandlerID
+	s.handlers = append(s.handlers, eventHandler{id: id, fn: fn})
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, h := range s.handlers {
+			if h.id == id {
+				s.handlers = append(s.handlers[:i:i], s.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Close — отключается от брокера и закрывает Events и каналы DeviceStates.
+// После Close подписку нельзя подключить повторно.
+func (s *Subscriber) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Disconnect(disconnectQuiesce)
+		s.conn = nil
+		s.Logger.Info("MQTT session closed")
+	}
+	close(s.events)
+	streams := s.streams
+	s.streams = nil
+	s.handlers = nil
+	s.mu.Unlock()
+
+	for stream := range streams {
+		stream.close()
+	}
+}
+
+// waitToken — ожидает завершения операции paho с учетом контекста
+func waitToken(ctx context.Context, token paho.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
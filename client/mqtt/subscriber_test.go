@@ -0,0 +1,213 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/savier89/daichi-ac-sdk/client"
+)
+
+const stateEvent = `{"type":"state","deviceId":7,"data":{"id":7,"state":{"isOn":true}}}`
+
+func TestDeviceStatesUnsubscribe(t *testing.T) {
+	broker := newFakeBroker()
+	s := connectFake(t, broker)
+
+	states, unsubscribe := s.DeviceStates()
+	broker.deliver("devices/7/state", []byte(stateEvent))
+
+	select {
+	case state := <-states:
+		if state.ID != 7 || !state.State.IsOn {
+			t.Errorf("state = %+v", state)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no state delivered")
+	}
+
+	unsubscribe()
+	unsubscribe() // Повторный вызов безопасен
+	if _, ok := <-states; ok {
+		t.Error("states channel is open after unsubscribe")
+	}
+
+	s.mu.Lock()
+	handlers, streams := len(s.handlers), len(s.streams)
+	s.mu.Unlock()
+	if handlers != 0 || streams != 0 {
+		t.Errorf("after unsubscribe: %d handlers, %d streams, want none", handlers, streams)
+	}
+
+	// Доставка после отписки не паникует и не блокируется
+	broker.deliver("devices/7/state", []byte(stateEvent))
+}
+
+func TestOnEventUnsubscribe(t *testing.T) {
+	broker := newFakeBroker()
+	s := connectFake(t, broker)
+
+	var first, second int
+	removeFirst := s.OnEvent(func(DeviceEvent) { first++ })
+	s.OnEvent(func(DeviceEvent) { second++ })
+
+	broker.deliver("devices/7/state", []byte(stateEvent))
+	removeFirst()
+	broker.deliver("devices/7/state", []byte(stateEvent))
+
+	if first != 1 || second != 2 {
+		t.Errorf("handler calls = %d, %d, want 1, 2", first, second)
+	}
+}
+
+func TestCloseClosesChannels(t *testing.T) {
+	broker := newFakeBroker()
+	s := connectFake(t, broker)
+	states, _ := s.DeviceStates()
+
+	broker.deliver("devices/7/state", []byte(stateEvent))
+	s.Close()
+	s.Close()
+
+	// Буферизованное событие доступно, затем канал закрыт
+	events := 0
+	for range s.Events() {
+		events++
+	}
+	if events != 1 {
+		t.Errorf("drained %d events, want 1", events)
+	}
+	for range states {
+	}
+
+	if _, ok := <-states; ok {
+		t.Error("states channel is open after Close")
+	}
+	if err := s.Connect(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Connect after Close = %v, want ErrClosed", err)
+	}
+
+	// Сообщения, пришедшие во время отключения, не паникуют
+	broker.deliver("devices/7/state", []byte(stateEvent))
+	if after, _ := s.DeviceStates(); after == nil {
+		t.Error("DeviceStates after Close returned nil channel")
+	} else if _, ok := <-after; ok {
+		t.Error("DeviceStates after Close returned an open channel")
+	}
+}
+
+func TestSubscribesToDeviceTopics(t *testing.T) {
+	broker := newFakeBroker()
+	connectFake(t, broker, WithDeviceIDs(8))
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	for _, topic := range []string{"devices/7/#", "devices/8/#", DefaultCommandResponseTopic} {
+		if _, ok := broker.subs[topic]; !ok {
+			t.Errorf("not subscribed to %s", topic)
+		}
+	}
+	if len(broker.subs) != 3 {
+		t.Errorf("subscriptions = %d, want 3", len(broker.subs))
+	}
+}
+
+func TestConnectWithoutTopics(t *testing.T) {
+	s := newFakeSubscriber(t, newFakeBroker())
+	if err := s.Connect(context.Background()); !errors.Is(err, ErrNoTopics) {
+		t.Errorf("Connect err = %v, want ErrNoTopics", err)
+	}
+}
+
+func TestSubscribeTimeoutIsFailure(t *testing.T) {
+	broker := newFakeBroker()
+	broker.stallSubs = true
+
+	var logs bytes.Buffer
+	s := newFakeSubscriber(t, broker, WithDeviceIDs(7), WithLogger(client.NewLogger(client.LogInfo, &logs)))
+	s.subscribeTimeout = 10 * time.Millisecond
+	if err := s.Connect(context.Background()); !errors.Is(err, ErrSubscribeTimeout) {
+		t.Fatalf("Connect err = %v, want ErrSubscribeTimeout", err)
+	}
+
+	if broker.conn.IsConnected() {
+		t.Error("connection left open after a failed subscribe")
+	}
+	if strings.Contains(logs.String(), "Subscribed to MQTT topic") {
+		t.Errorf("timed out subscription reported as success:\n%s", logs.String())
+	}
+}
+
+func TestResubscribesAfterReconnect(t *testing.T) {
+	broker := newFakeBroker()
+	connectFake(t, broker)
+
+	broker.mu.Lock()
+	broker.subs = make(map[string]paho.MessageHandler)
+	conn := broker.conn
+	broker.mu.Unlock()
+	conn.reconnect()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		broker.mu.Lock()
+		_, ok := broker.subs["devices/7/#"]
+		broker.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("not resubscribed after reconnect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeviceIDsFromBuildings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"done":true,"data":[{"id":1,"places":[{"id":7},{"id":8}]},{"id":2,"places":[{"id":9}]}]}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := client.NewAuthorizedDaichiClient(ctx, "user@example.com", "password", client.WithBaseURL(srv.URL), client.WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	ids, err := deviceIDs(ctx, c)
+	if err != nil {
+		t.Fatalf("deviceIDs: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int{7, 8, 9}) {
+		t.Errorf("ids = %v, want [7 8 9]", ids)
+	}
+}
+
+func TestStateEventsReportUnknownEnums(t *testing.T) {
+	var logs bytes.Buffer
+	broker := newFakeBroker()
+	connectFake(t, broker, WithLogger(client.NewLogger(client.LogWarn, &logs)))
+
+	event := []byte(`{"type":"state","deviceId":7,"data":{"id":7,"access":"GUEST"}}`)
+	broker.deliver("devices/7/state", event)
+	broker.deliver("devices/7/state", event)
+
+	if n := strings.Count(logs.String(), `Unknown client.Access value from API: "GUEST"`); n != 1 {
+		t.Errorf("unknown access reported %d times, want 1:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithRateLimit — ограничивает частоту запросов (token bucket).
+// Чтение и команды управления получают отдельные бюджеты с этими параметрами.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *DaichiClient) {
+		c.rateLimit = &rateLimitConfig{rps: rps, burst: burst}
+	}
+}
+
+// WithControlRateLimit — задает отдельный бюджет для команд управления.
+// Действует независимо от порядка относительно WithRateLimit.
+func WithControlRateLimit(rps float64, burst int) Option {
+	return func(c *DaichiClient) {
+		c.controlRateLimit = &rateLimitConfig{rps: rps, burst: burst}
+	}
+}
+
+// rateLimitConfig — параметры token bucket из опций
+type rateLimitConfig struct {
+	rps   float64
+	burst int
+}
+
+// buildRateLimiters — создает ограничители после применения всех опций
+func (c *DaichiClient) buildRateLimiters() {
+	if c.rateLimit != nil {
+		c.readLimiter = newRateLimiter(c.rateLimit.rps, c.rateLimit.burst)
+		c.controlLimiter = newRateLimiter(c.rateLimit.rps, c.rateLimit.burst)
+	}
+	if c.controlRateLimit != nil {
+		c.controlLimiter = newRateLimiter(c.controlRateLimit.rps, c.controlRateLimit.burst)
+	}
+}
+
+// rateLimiter — token bucket
+type rateLimiter struct {
+	rps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// newRateLimiter — создает ограничитель; rps <= 0 отключает ограничение
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rps:    rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve — забирает токен и возвращает, сколько нужно подождать
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rps
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rps * float64(time.Second))
+}
+
+// cancel — возвращает токен, если ожидание прервано
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens++
+}
+
+// Wait — ждет разрешения на запрос с учетом отмены контекста
+func (l *rateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	delay := l.reserve()
+	if delay <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		l.cancel()
+		return 0, ctx.Err()
+	}
+}
+
+// rateLimitRoundTripper ограничивает частоту запросов
+type rateLimitRoundTripper struct {
+	transport http.RoundTripper
+	read      *rateLimiter
+	control   *rateLimiter
+	logger    *Logger
+}
+
+// RoundTrip реализует интерфейс http.RoundTripper
+func (rt *rateLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter, kind := rt.read, "read"
+	skipAuth, _ := req.Context().Value(skipAuthKey{}).(bool)
+	if req.Method != http.MethodGet && req.Method != http.MethodHead && !skipAuth {
+		limiter, kind = rt.control, "control"
+	}
+
+	if limiter != nil {
+		waited, err := limiter.Wait(req.Context())
+		if err != nil {
+			rt.logger.Warn("Rate-limited %s request %s cancelled: %v", kind, req.URL.Path, err)
+			return nil, err
+		}
+		if waited > 0 {
+			rt.logger.Debug("Rate limiter delayed %s request %s by %s", kind, req.URL.Path, waited)
+		}
+	}
+
+	return rt.transport.RoundTrip(req)
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestControlRateLimitIsOrderIndependent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"control first", []Option{WithControlRateLimit(1, 2), WithRateLimit(10, 20)}},
+		{"control last", []Option{WithRateLimit(10, 20), WithControlRateLimit(1, 2)}},
+	}
+
+	for _, tt := range tests {
+		c := NewDaichiClient(append(tt.opts, WithNoLogs())...)
+		if c.readLimiter == nil || c.readLimiter.rps != 10 || c.readLimiter.burst != 20 {
+			t.Errorf("%s: read limiter = %+v, want 10 rps, burst 20", tt.name, c.readLimiter)
+		}
+		if c.controlLimiter == nil || c.controlLimiter.rps != 1 || c.controlLimiter.burst != 2 {
+			t.Errorf("%s: control limiter = %+v, want 1 rps, burst 2", tt.name, c.controlLimiter)
+		}
+		if c.readLimiter == c.controlLimiter {
+			t.Errorf("%s: read and control share a budget", tt.name)
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	c := NewDaichiClient(WithRateLimit(0, 5), WithNoLogs())
+	if c.readLimiter != nil || c.controlLimiter != nil {
+		t.Errorf("limiters = %v, %v, want none for rps 0", c.readLimiter, c.controlLimiter)
+	}
+}
+
+func TestRateLimiterReserve(t *testing.T) {
+	l := newRateLimiter(10, 2)
+
+	for i := 0; i < 2; i++ {
+		if delay := l.reserve(); delay != 0 {
+			t.Fatalf("request %d within burst delayed by %s", i+1, delay)
+		}
+	}
+	// Третий запрос ждет пополнения одного токена: 1/10 секунды
+	if delay := l.reserve(); delay < 90*time.Millisecond || delay > 100*time.Millisecond {
+		t.Errorf("delay after burst = %s, want about 100ms", delay)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	l.reserve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait err = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Отмененное ожидание возвращает токен: следующий запрос ждет не дольше секунды
+	if delay := l.reserve(); delay > time.Second {
+		t.Errorf("delay after cancelled wait = %s, want at most 1s", delay)
+	}
+}
+
+func TestRateLimitRoundTripperSeparatesBudgets(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	rt := &rateLimitRoundTripper{
+		transport: http.DefaultTransport,
+		read:      newRateLimiter(1000, 10),
+		control:   newRateLimiter(0.001, 1),
+		logger:    NewLogger(LogNone, nil),
+	}
+	send := func(ctx context.Context, method string) error {
+		req, _ := http.NewRequestWithContext(ctx, method, srv.URL, nil)
+		resp, err := rt.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	ctx := context.Background()
+	if err := send(ctx, http.MethodPost); err != nil {
+		t.Fatalf("first command: %v", err)
+	}
+	// Бюджет команд исчерпан, чтение от него не зависит
+	for i := 0; i < 5; i++ {
+		if err := send(ctx, http.MethodGet); err != nil {
+			t.Fatalf("read %d: %v", i+1, err)
+		}
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := send(short, http.MethodPost); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second command err = %v, want context.DeadlineExceeded", err)
+	}
+	if n := requests.Load(); n != 6 {
+		t.Errorf("requests = %d, want 6", n)
+	}
+}
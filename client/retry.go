@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy — политика повторов с экспоненциальной задержкой.
+// GET/HEAD/OPTIONS повторяются всегда, остальные запросы — только с контекстом RetrySafe.
+type RetryPolicy struct {
+	MaxAttempts   int              // Всего попыток, включая первую
+	BaseDelay     time.Duration    // Задержка перед первым повтором
+	MaxDelay      time.Duration    // Верхняя граница задержки (кроме Retry-After); 0 — одна минута
+	Jitter        float64          // Доля случайного уменьшения задержки, от 0 до 1
+	RetryStatuses []int            // Коды ответа для повтора
+	RetryOn       func(error) bool // Какие ошибки повторять; по умолчанию все, кроме отмены контекста и открытого Circuit Breaker
+}
+
+// DefaultRetryPolicy — политика по умолчанию для WithRetryPolicy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.5,
+		RetryStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetryPolicy — включает повторы запросов
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *DaichiClient) {
+		c.retryPolicy = &policy
+	}
+}
+
+// retrySafeKey — ключ контекста для запросов, которые безопасно повторять
+type retrySafeKey struct{}
+
+// RetrySafe — помечает неидемпотентный запрос (например, команду управления) как безопасный для повтора
+func RetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// retryable — можно ли повторять запрос
+func (p *RetryPolicy) retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// retryStatus — нужно ли повторять ответ с этим кодом
+func (p *RetryPolicy) retryStatus(code int) bool {
+	for _, status := range p.RetryStatuses {
+		if status == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryError — нужно ли повторять запрос после ошибки
+func (p *RetryPolicy) retryError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrCircuitBreakerOpen) {
+		return false
+	}
+	if p.RetryOn != nil {
+		return p.RetryOn(err)
+	}
+	return true
+}
+
+// maxRetryDelay — верхняя граница задержки, если MaxDelay не задан
+const maxRetryDelay = time.Minute
+
+// backoff — задержка перед повтором с номером attempt (начиная с 1)
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	limit := p.MaxDelay
+	if limit <= 0 {
+		limit = maxRetryDelay
+	}
+	// Сдвиг ограничен, чтобы задержка не переполнилась и не стала нулевой или отрицательной
+	delay := limit
+	if shift := max(attempt-1, 0); p.BaseDelay > 0 && shift < 63 && p.BaseDelay <= limit>>shift {
+		delay = p.BaseDelay << shift
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// retryAfter — разбирает заголовок Retry-After (секунды или HTTP-дата)
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// retryRoundTripper повторяет запросы по RetryPolicy
+type retryRoundTripper struct {
+	transport http.RoundTripper
+	policy    *RetryPolicy
+	logger    *Logger
+}
+
+// RoundTrip реализует интерфейс http.RoundTripper
+func (rt *retryRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.policy.MaxAttempts <= 1 || !rt.policy.retryable(req) {
+		return rt.transport.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, errors.New("cannot retry request: body is not replayable")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := rt.transport.RoundTrip(attemptReq)
+		last := attempt >= rt.policy.MaxAttempts
+
+		var delay time.Duration
+		switch {
+		case err != nil:
+			if last || !rt.policy.retryError(err) {
+				return nil, err
+			}
+			delay = rt.policy.backoff(attempt)
+			rt.logger.Warn("Request %s failed (attempt %d/%d), retrying in %s: %v", req.URL.Path, attempt, rt.policy.MaxAttempts, delay, err)
+
+		case rt.policy.retryStatus(resp.StatusCode):
+			if last {
+				return resp, nil
+			}
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			} else {
+				delay = rt.policy.backoff(attempt)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			rt.logger.Warn("Request %s returned %d (attempt %d/%d), retrying in %s", req.URL.Path, resp.StatusCode, attempt, rt.policy.MaxAttempts, delay)
+
+		default:
+			return resp, nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
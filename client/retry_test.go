@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 1, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 3, 400 * time.Millisecond},
+		{"capped by MaxDelay", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 5, time.Second},
+		{"overflow with MaxDelay", RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, 100, time.Second},
+		{"overflow without MaxDelay", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 100, maxRetryDelay},
+		{"shift of 63 without MaxDelay", RetryPolicy{BaseDelay: 1}, 64, maxRetryDelay},
+		{"zero attempt", RetryPolicy{BaseDelay: 100 * time.Millisecond}, 0, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.backoff(tt.attempt); got != tt.want {
+			t.Errorf("%s: backoff(%d) = %s, want %s", tt.name, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffJitterStaysPositive(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+	for attempt := 1; attempt <= 100; attempt++ {
+		if delay := policy.backoff(attempt); delay <= 0 || delay > maxRetryDelay {
+			t.Fatalf("backoff(%d) = %s, want within (0, %s]", attempt, delay, maxRetryDelay)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"absent", "", 0, false},
+		{"seconds", "3", 3 * time.Second, true},
+		{"zero seconds", "0", 0, true},
+		{"negative seconds", "-1", 0, false},
+		{"garbage", "soon", 0, false},
+		{"http date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.ok)
+		}
+		// HTTP-дата имеет точность в секунду
+		if diff := got - tt.want; diff < -time.Second || diff > time.Second {
+			t.Errorf("%s: delay = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newRetryRoundTripper — retryRoundTripper поверх сервера, который отвечает status на первые failures запросов
+func newRetryRoundTripper(t *testing.T, failures int32, status int, header http.Header) (*retryRoundTripper, *httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.Jitter = 0
+	rt := &retryRoundTripper{transport: http.DefaultTransport, policy: &policy, logger: NewLogger(LogNone, nil)}
+	return rt, srv, &requests
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	rt, srv, requests := newRetryRoundTripper(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	start := time.Now()
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("status = %d after %d requests, want 200 after 2", resp.StatusCode, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want Retry-After of 1s", elapsed)
+	}
+}
+
+func TestRetrySkipsNonIdempotentMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		safe     bool
+		requests int32
+	}{
+		{"GET", http.MethodGet, false, 2},
+		{"POST", http.MethodPost, false, 1},
+		{"PUT", http.MethodPut, false, 1},
+		{"POST marked RetrySafe", http.MethodPost, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, srv, requests := newRetryRoundTripper(t, 1, http.StatusServiceUnavailable, nil)
+
+			ctx := context.Background()
+			if tt.safe {
+				ctx = RetrySafe(ctx)
+			}
+			req, _ := http.NewRequestWithContext(ctx, tt.method, srv.URL, strings.NewReader(`{"value":1}`))
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			resp.Body.Close()
+
+			if n := requests.Load(); n != tt.requests {
+				t.Errorf("requests = %d, want %d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnContextCancellation(t *testing.T) {
+	rt, srv, requests := newRetryRoundTripper(t, 10, http.StatusServiceUnavailable, nil)
+	rt.policy.BaseDelay = time.Minute
+	rt.policy.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	start := time.Now()
+	if _, err := rt.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RoundTrip err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("RoundTrip returned after %s, want it to stop waiting on cancellation", elapsed)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
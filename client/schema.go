@@ -0,0 +1,325 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SchemaDriftReporter — вызывается для каждого поля ответа, которого нет в модели SDK
+// или форма которого не совпала с моделью; о каждом поле клиент сообщает один раз
+type SchemaDriftReporter func(typeName, field string)
+
+// WithSchemaDriftReporter — сообщает о новых полях API до того, как они что-то сломают
+func WithSchemaDriftReporter(reporter SchemaDriftReporter) Option {
+	return func(c *DaichiClient) {
+		c.schemaDriftReporter = reporter
+	}
+}
+
+// UnmarshalJSON сохраняет неизвестные поля в Extra
+func (u *DaichiUser) UnmarshalJSON(data []byte) error {
+	type plain DaichiUser
+	extra, err := decodeWithExtra(data, (*plain)(u))
+	u.Extra = extra
+	return err
+}
+
+// MarshalJSON возвращает неизвестные поля из Extra обратно в JSON
+func (u DaichiUser) MarshalJSON() ([]byte, error) {
+	type plain DaichiUser
+	return encodeWithExtra(plain(u), u.Extra)
+}
+
+// UnmarshalJSON сохраняет неизвестные поля в Extra
+func (b *DaichiBuilding) UnmarshalJSON(data []byte) error {
+	type plain DaichiBuilding
+	extra, err := decodeWithExtra(data, (*plain)(b))
+	b.Extra = extra
+	return err
+}
+
+// MarshalJSON возвращает неизвестные поля из Extra обратно в JSON
+func (b DaichiBuilding) MarshalJSON() ([]byte, error) {
+	type plain DaichiBuilding
+	return encodeWithExtra(plain(b), b.Extra)
+}
+
+// UnmarshalJSON сохраняет неизвестные поля в Extra
+func (d *DaichiBuildingDeviceStruct) UnmarshalJSON(data []byte) error {
+	type plain DaichiBuildingDeviceStruct
+	extra, err := decodeWithExtra(data, (*plain)(d))
+	d.Extra = extra
+	return err
+}
+
+// MarshalJSON возвращает неизвестные поля из Extra обратно в JSON
+func (d DaichiBuildingDeviceStruct) MarshalJSON() ([]byte, error) {
+	type plain DaichiBuildingDeviceStruct
+	return encodeWithExtra(plain(d), d.Extra)
+}
+
+// Модели ниже описаны по единичным ответам API и могут не совпасть с реальной формой.
+// Несовпадение не должно ломать весь ответ: значение сохраняется в Raw и возвращается в MarshalJSON.
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (p *Progress) UnmarshalJSON(data []byte) error {
+	type plain Progress
+	*p = Progress{}
+	p.Raw = decodeTolerant(data, (*plain)(p))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (p Progress) MarshalJSON() ([]byte, error) {
+	type plain Progress
+	return encodeTolerant(plain(p), p.Raw)
+}
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (p *DevicePreset) UnmarshalJSON(data []byte) error {
+	type plain DevicePreset
+	*p = DevicePreset{}
+	p.Raw = decodeTolerant(data, (*plain)(p))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (p DevicePreset) MarshalJSON() ([]byte, error) {
+	type plain DevicePreset
+	return encodeTolerant(plain(p), p.Raw)
+}
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (t *DeviceTimer) UnmarshalJSON(data []byte) error {
+	type plain DeviceTimer
+	*t = DeviceTimer{}
+	t.Raw = decodeTolerant(data, (*plain)(t))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (t DeviceTimer) MarshalJSON() ([]byte, error) {
+	type plain DeviceTimer
+	return encodeTolerant(plain(t), t.Raw)
+}
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (s *DeviceSubscription) UnmarshalJSON(data []byte) error {
+	type plain DeviceSubscription
+	*s = DeviceSubscription{}
+	s.Raw = decodeTolerant(data, (*plain)(s))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (s DeviceSubscription) MarshalJSON() ([]byte, error) {
+	type plain DeviceSubscription
+	return encodeTolerant(plain(s), s.Raw)
+}
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (g *GeoTrigger) UnmarshalJSON(data []byte) error {
+	type plain GeoTrigger
+	*g = GeoTrigger{}
+	g.Raw = decodeTolerant(data, (*plain)(g))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (g GeoTrigger) MarshalJSON() ([]byte, error) {
+	type plain GeoTrigger
+	return encodeTolerant(plain(g), g.Raw)
+}
+
+// UnmarshalJSON не возвращает ошибку, если форма ответа не совпала с моделью
+func (r *AccessRequest) UnmarshalJSON(data []byte) error {
+	type plain AccessRequest
+	*r = AccessRequest{}
+	r.Raw = decodeTolerant(data, (*plain)(r))
+	return nil
+}
+
+// MarshalJSON возвращает исходный JSON, если он не совпал с моделью
+func (r AccessRequest) MarshalJSON() ([]byte, error) {
+	type plain AccessRequest
+	return encodeTolerant(plain(r), r.Raw)
+}
+
+// decodeTolerant — декодирует data в v; при несовпадении формы возвращает копию исходного JSON
+func decodeTolerant(data []byte, v any) json.RawMessage {
+	if err := json.Unmarshal(data, v); err != nil {
+		return append(json.RawMessage(nil), data...)
+	}
+	return nil
+}
+
+// encodeTolerant — кодирует v или возвращает исходный JSON, если он был сохранен
+func encodeTolerant(v any, raw json.RawMessage) ([]byte, error) {
+	if raw != nil {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
+
+// knownFields — кэш JSON-ключей моделей (в нижнем регистре, как их сопоставляет encoding/json)
+var knownFields sync.Map
+
+// jsonFields — JSON-ключи полей структуры
+func jsonFields(t reflect.Type) map[string]bool {
+	if fields, ok := knownFields.Load(t); ok {
+		return fields.(map[string]bool)
+	}
+
+	fields := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+
+	knownFields.Store(t, fields)
+	return fields
+}
+
+// decodeWithExtra — декодирует объект в v и возвращает поля, которых нет в модели
+func decodeWithExtra(data []byte, v any) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil // null или не объект — лишних полей нет
+	}
+
+	known := jsonFields(reflect.TypeOf(v).Elem())
+	var extra map[string]json.RawMessage
+	for key, value := range raw {
+		if known[strings.ToLower(key)] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+// encodeWithExtra — кодирует v и добавляет поля из extra, которых нет в модели
+func encodeWithExtra(v any, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// reportSchemaDrift — сообщает о неизвестных полях модели
+func (c *DaichiClient) reportSchemaDrift(typeName string, extra map[string]json.RawMessage) {
+	if len(extra) == 0 {
+		return
+	}
+
+	fields := make([]string, 0, len(extra))
+	for field := range extra {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if !c.firstDriftReport(typeName, field) {
+			continue
+		}
+		c.Logger.Debug("Unmodelled field in %s: %s", typeName, field)
+		if c.schemaDriftReporter != nil {
+			c.schemaDriftReporter(typeName, field)
+		}
+	}
+}
+
+// firstDriftReport — true, если о поле typeName.field клиент еще не сообщал
+func (c *DaichiClient) firstDriftReport(typeName, field string) bool {
+	_, loaded := c.reportedDrift.LoadOrStore(typeName+"."+field, true)
+	return !loaded
+}
+
+// reportShapeDrift — сообщает о поле, форма которого не совпала с моделью
+func (c *DaichiClient) reportShapeDrift(typeName, field string, raw json.RawMessage) {
+	if raw == nil || !c.firstDriftReport(typeName, field) {
+		return
+	}
+	c.Logger.Debug("Unexpected shape of %s.%s: %s", typeName, field, redactJSON(raw))
+	if c.schemaDriftReporter != nil {
+		c.schemaDriftReporter(typeName, field)
+	}
+}
+
+// reportUserDrift — проверяет пользователя на новые поля
+func (c *DaichiClient) reportUserDrift(u *DaichiUser) {
+	c.reportSchemaDrift("DaichiUser", u.Extra)
+	for _, request := range u.AccessRequests {
+		c.reportShapeDrift("DaichiUser", "accessRequests", request.Raw)
+	}
+}
+
+// reportBuildingDrift — проверяет здание и его устройства на новые поля
+func (c *DaichiClient) reportBuildingDrift(b *DaichiBuilding) {
+	c.reportSchemaDrift("DaichiBuilding", b.Extra)
+	if b.TriggeredBy != nil {
+		c.reportShapeDrift("DaichiBuilding", "triggeredBy", b.TriggeredBy.Raw)
+	}
+	if b.OwnTrigger != nil {
+		c.reportShapeDrift("DaichiBuilding", "ownTrigger", b.OwnTrigger.Raw)
+	}
+	for i := range b.Places {
+		c.reportDeviceDrift(&b.Places[i])
+	}
+}
+
+// reportDeviceDrift — проверяет устройство на новые поля
+func (c *DaichiClient) reportDeviceDrift(d *DaichiBuildingDeviceStruct) {
+	const typeName = "DaichiBuildingDeviceStruct"
+	c.reportSchemaDrift(typeName, d.Extra)
+	if d.GroupID != nil {
+		if _, ok := d.GroupID.Int(); !ok {
+			c.reportShapeDrift(typeName, "groupId", json.RawMessage(strconv.Quote(string(*d.GroupID))))
+		}
+	}
+	if d.Progress != nil {
+		c.reportShapeDrift(typeName, "progress", d.Progress.Raw)
+	}
+	if d.CurrentPreset != nil {
+		c.reportShapeDrift(typeName, "currentPreset", d.CurrentPreset.Raw)
+	}
+	if d.Timer != nil {
+		c.reportShapeDrift(typeName, "timer", d.Timer.Raw)
+	}
+	if d.Subscription != nil {
+		c.reportShapeDrift(typeName, "subscription", d.Subscription.Raw)
+	}
+}
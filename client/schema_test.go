@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readFixture — поле data из ответа API в testdata.
+// Фикстуры составлены вручную по моделям SDK, записанных ответов API в репозитории нет.
+func readFixture(t *testing.T, name string) json.RawMessage {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("decode fixture %s: %v", name, err)
+	}
+	return envelope.Data
+}
+
+// assertRoundTrip — проверяет, что после декодирования и кодирования не потеряно ни одно значение из data
+func assertRoundTrip(t *testing.T, data json.RawMessage, v any) {
+	t.Helper()
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var want, got any
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(encoded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := subsetDiff("$", want, got); diff != "" {
+		t.Errorf("round trip lost data: %s\nencoded: %s", diff, encoded)
+	}
+}
+
+// subsetDiff — описание первого значения из want, которого нет в got.
+// Поля, которые SDK добавляет со значением по умолчанию, не считаются расхождением.
+func subsetDiff(path string, want, got any) string {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			return fmt.Sprintf("%s: want object, got %v", path, got)
+		}
+		keys := make([]string, 0, len(w))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := g[key]
+			if !ok {
+				if isZeroJSON(w[key]) {
+					continue
+				}
+				return fmt.Sprintf("%s.%s: missing", path, key)
+			}
+			if diff := subsetDiff(path+"."+key, w[key], value); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			return fmt.Sprintf("%s: want %v, got %v", path, want, got)
+		}
+		for i := range w {
+			if diff := subsetDiff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); diff != "" {
+				return diff
+			}
+		}
+		return ""
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return fmt.Sprintf("%s: want %v, got %v", path, want, got)
+		}
+		return ""
+	}
+}
+
+// isZeroJSON — значение, которое omitempty вправе опустить
+func isZeroJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func TestFixturesRoundTrip(t *testing.T) {
+	t.Run("buildings", func(t *testing.T) {
+		var buildings []DaichiBuilding
+		assertRoundTrip(t, readFixture(t, "buildings.json"), &buildings)
+
+		device := buildings[0].Places[0]
+		if id, ok := device.GroupID.Int(); !ok || id != 7 {
+			t.Errorf("GroupID = %v, want 7", device.GroupID)
+		}
+		if device.Progress == nil || device.Progress.Percent != 40 || device.Progress.Raw != nil {
+			t.Errorf("Progress = %+v, want 40%%", device.Progress)
+		}
+		if device.Extra["newServerField"] == nil || buildings[0].Extra["newBuildingField"] == nil {
+			t.Error("unknown fields were not kept in Extra")
+		}
+
+		state := device.OperatingState()
+		if state.Mode != ModeCool || state.TargetTemperature == nil || *state.TargetTemperature != 22 {
+			t.Errorf("OperatingState = %+v, want cool at 22°", state)
+		}
+		if len(state.Unknown) != 0 {
+			t.Errorf("OperatingState.Unknown = %q, want none", state.Unknown)
+		}
+	})
+
+	t.Run("unexpected shapes", func(t *testing.T) {
+		var buildings []DaichiBuilding
+		assertRoundTrip(t, readFixture(t, "buildings_unexpected_shapes.json"), &buildings)
+
+		building := buildings[0]
+		device := building.Places[0]
+		if *device.GroupID != "abc" {
+			t.Errorf("GroupID = %q, want abc", *device.GroupID)
+		}
+		if _, ok := device.GroupID.Int(); ok {
+			t.Error("non-numeric GroupID reported as int")
+		}
+		for name, raw := range map[string]json.RawMessage{
+			"progress":      device.Progress.Raw,
+			"currentPreset": device.CurrentPreset.Raw,
+			"timer":         device.Timer.Raw,
+			"subscription":  device.Subscription.Raw,
+			"triggeredBy":   building.TriggeredBy.Raw,
+			"ownTrigger":    building.OwnTrigger.Raw,
+		} {
+			if raw == nil {
+				t.Errorf("%s: raw value was not kept", name)
+			}
+		}
+	})
+
+	t.Run("user", func(t *testing.T) {
+		var user DaichiUser
+		assertRoundTrip(t, readFixture(t, "user.json"), &user)
+
+		if len(user.AccessRequests) != 2 || user.AccessRequests[0].Raw != nil || user.AccessRequests[1].Raw == nil {
+			t.Errorf("AccessRequests = %+v", user.AccessRequests)
+		}
+	})
+}
+
+func TestGetBuildingsToleratesUnexpectedShapes(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "buildings_unexpected_shapes.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	var drift []string
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs(),
+		WithSchemaDriftReporter(func(typeName, field string) {
+			drift = append(drift, typeName+"."+field)
+		}))
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	// О каждом поле сообщается один раз, сколько бы ответов его ни содержали
+	for i := 0; i < 2; i++ {
+		buildings, err := c.GetBuildings(ctx)
+		if err != nil {
+			t.Fatalf("GetBuildings: %v", err)
+		}
+		if len(buildings) != 1 || len(buildings[0].Places) != 1 {
+			t.Fatalf("buildings = %+v", buildings)
+		}
+	}
+
+	sort.Strings(drift)
+	want := []string{
+		"DaichiBuilding.ownTrigger",
+		"DaichiBuilding.triggeredBy",
+		"DaichiBuildingDeviceStruct.currentPreset",
+		"DaichiBuildingDeviceStruct.groupId",
+		"DaichiBuildingDeviceStruct.progress",
+		"DaichiBuildingDeviceStruct.subscription",
+		"DaichiBuildingDeviceStruct.timer",
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("reported drift = %v, want %v", drift, want)
+	}
+}
+
+func TestSchemaDriftReportedOncePerClient(t *testing.T) {
+	var drift []string
+	reporter := WithSchemaDriftReporter(func(typeName, field string) {
+		drift = append(drift, typeName+"."+field)
+	})
+	first, second := NewDaichiClient(WithNoLogs(), reporter), NewDaichiClient(WithNoLogs(), reporter)
+
+	extra := map[string]json.RawMessage{"newField": json.RawMessage(`1`), "otherField": json.RawMessage(`"x"`)}
+	for _, c := range []*DaichiClient{first, first, second} {
+		c.reportSchemaDrift("DaichiBuilding", extra)
+		c.reportSchemaDrift("DaichiUser", map[string]json.RawMessage{"newField": json.RawMessage(`1`)})
+	}
+
+	// Одно и то же поле разных типов — разные записи; второй клиент сообщает независимо от первого
+	want := []string{
+		"DaichiBuilding.newField", "DaichiBuilding.otherField", "DaichiUser.newField",
+		"DaichiBuilding.newField", "DaichiBuilding.otherField", "DaichiUser.newField",
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Errorf("reported drift = %v, want %v", drift, want)
+	}
+}
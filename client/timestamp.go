@@ -0,0 +1,203 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampLayouts — форматы дат, которые встречаются в ответах API
+var timestampLayouts = []struct {
+	layout string
+	zoned  bool // Содержит ли формат часовой пояс
+}{
+	{time.RFC3339Nano, true},
+	{"2006-01-02T15:04:05.999999999", false},
+	{"2006-01-02 15:04:05.999999999Z07:00", true},
+	{"2006-01-02 15:04:05.999999999", false},
+	{"2006-01-02", false},
+}
+
+// NullTime — метка времени из API; Valid == false, если значение отсутствует или не разобрано.
+// Даты без часового пояса разбираются как UTC; клиент уточняет их часовым поясом здания
+// устройства (GetBuildings, GetDeviceState, ControlDevice), для остальных есть AssumeLocation.
+type NullTime struct {
+	Time  time.Time
+	Valid bool
+	naive bool   // Дата пришла без часового пояса
+	raw   string // Исходное значение, если его не удалось разобрать
+}
+
+// UnmarshalJSON принимает строку даты, Unix-время в секундах или миллисекундах и null.
+// Неразобранное значение не считается ошибкой и сохраняется для MarshalJSON.
+func (t *NullTime) UnmarshalJSON(data []byte) error {
+	*t = NullTime{}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	if parsed, zoned, ok := parseTimestamp(s); ok {
+		t.Time, t.Valid, t.naive = parsed, true, !zoned
+		return nil
+	}
+	t.raw = s
+	return nil
+}
+
+// MarshalJSON — RFC 3339 для разобранных значений, исходная строка или null для остальных
+func (t NullTime) MarshalJSON() ([]byte, error) {
+	if t.Valid {
+		return json.Marshal(t.Time.Format(time.RFC3339Nano))
+	}
+	if t.raw != "" {
+		return json.Marshal(t.raw)
+	}
+	return []byte("null"), nil
+}
+
+// String — дата в RFC 3339 или пустая строка
+func (t NullTime) String() string {
+	if !t.Valid {
+		return t.raw
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+// In — время в указанном часовом поясе (например, b.Location())
+func (t NullTime) In(loc *time.Location) time.Time {
+	if !t.Valid || loc == nil {
+		return t.Time
+	}
+	return t.Time.In(loc)
+}
+
+// AssumeLocation — толкует дату без часового пояса как местное время loc.
+// Даты с часовым поясом и Unix-время не меняются.
+func (t NullTime) AssumeLocation(loc *time.Location) NullTime {
+	if !t.Valid || !t.naive || loc == nil {
+		return t
+	}
+	wall := t.Time
+	t.Time = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	t.naive = false
+	return t
+}
+
+// parseTimestamp — разбирает дату в одном из известных форматов или Unix-время.
+// zoned == false, если в строке не было часового пояса.
+func parseTimestamp(s string) (parsed time.Time, zoned bool, ok bool) {
+	for _, format := range timestampLayouts {
+		if parsed, err := time.Parse(format.layout, s); err == nil {
+			return parsed, format.zoned, true
+		}
+	}
+
+	unix, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || unix <= 0 {
+		return time.Time{}, false, false
+	}
+	if unix > 1e12 {
+		return time.UnixMilli(unix).UTC(), true, true
+	}
+	return time.Unix(unix, 0).UTC(), true, true
+}
+
+// Location — часовой пояс здания: TimeZone (IANA), иначе смещение UTC в часах
+func (b *DaichiBuilding) Location() *time.Location {
+	if b.TimeZone != "" {
+		if loc, err := time.LoadLocation(b.TimeZone); err == nil {
+			return loc
+		}
+	}
+
+	offset := b.UTC * 3600
+	if offset == 0 {
+		return time.UTC
+	}
+	return time.FixedZone("UTC"+formatUTCOffset(offset), offset)
+}
+
+// formatUTCOffset — смещение в виде «+03:00»
+func formatUTCOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	return sign + time.Date(0, 1, 1, 0, 0, seconds, 0, time.UTC).Format("15:04")
+}
+
+// localizeTimes — толкует даты здания и его устройств без часового пояса в loc
+func (b *DaichiBuilding) localizeTimes(loc *time.Location) {
+	for _, trigger := range []*GeoTrigger{b.TriggeredBy, b.OwnTrigger} {
+		if trigger != nil {
+			trigger.Date = trigger.Date.AssumeLocation(loc)
+		}
+	}
+	for i := range b.Places {
+		b.Places[i].localizeTimes(loc)
+	}
+}
+
+// localizeTimes — толкует даты устройства без часового пояса в loc
+func (d *DaichiBuildingDeviceStruct) localizeTimes(loc *time.Location) {
+	d.LastOnline = d.LastOnline.AssumeLocation(loc)
+	d.CreatedAt = d.CreatedAt.AssumeLocation(loc)
+	d.UpdatedAt = d.UpdatedAt.AssumeLocation(loc)
+	if d.Timer != nil {
+		d.Timer.FireAt = d.Timer.FireAt.AssumeLocation(loc)
+	}
+	if d.Subscription != nil {
+		d.Subscription.ExpiresAt = d.Subscription.ExpiresAt.AssumeLocation(loc)
+	}
+}
+
+// rememberLocation — запоминает часовой пояс здания для последующих ответов по его устройствам
+func (c *DaichiClient) rememberLocation(buildingID int, loc *time.Location) {
+	c.locationsMutex.Lock()
+	defer c.locationsMutex.Unlock()
+	if c.locations == nil {
+		c.locations = make(map[int]*time.Location)
+	}
+	c.locations[buildingID] = loc
+}
+
+// localizeDevice — уточняет даты устройства часовым поясом его здания, если он известен из GetBuildings
+func (c *DaichiClient) localizeDevice(d *DaichiBuildingDeviceStruct) {
+	if d == nil {
+		return
+	}
+	c.locationsMutex.RLock()
+	loc := c.locations[d.BuildingID]
+	c.locationsMutex.RUnlock()
+	if loc != nil {
+		d.localizeTimes(loc)
+	}
+}
+
+// OfflineFor — сколько устройство не в сети.
+// Возвращает 0 и true для устройства в сети, false — если время последнего подключения неизвестно.
+func (d *DaichiBuildingDeviceStruct) OfflineFor() (time.Duration, bool) {
+	if d.IsOnline() {
+		return 0, true
+	}
+	if !d.LastOnline.Valid {
+		return 0, false
+	}
+	return time.Since(d.LastOnline.Time), true
+}
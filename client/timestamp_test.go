@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNullTimeAssumeLocation(t *testing.T) {
+	moscow := time.FixedZone("UTC+03:00", 3*3600)
+	tests := []struct {
+		raw     string
+		wantUTC string
+	}{
+		{`"2024-05-01T12:00:00"`, "2024-05-01T09:00:00Z"},
+		{`"2024-05-01 12:00:00"`, "2024-05-01T09:00:00Z"},
+		{`"2024-05-01T12:00:00Z"`, "2024-05-01T12:00:00Z"},
+		{`"2024-05-01T12:00:00+05:00"`, "2024-05-01T07:00:00Z"},
+		{`1714564800`, "2024-05-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		var ts NullTime
+		if err := json.Unmarshal([]byte(tt.raw), &ts); err != nil || !ts.Valid {
+			t.Fatalf("unmarshal %s: valid=%v err=%v", tt.raw, ts.Valid, err)
+		}
+		local := ts.AssumeLocation(moscow)
+		if got := local.Time.UTC().Format(time.RFC3339); got != tt.wantUTC {
+			t.Errorf("%s in UTC+3 = %s, want %s", tt.raw, got, tt.wantUTC)
+		}
+		// Повторное уточнение не сдвигает время
+		if again := local.AssumeLocation(time.UTC); !again.Time.Equal(local.Time) {
+			t.Errorf("%s shifted twice: %s → %s", tt.raw, local.Time, again.Time)
+		}
+	}
+}
+
+func TestBuildingLocation(t *testing.T) {
+	tests := []struct {
+		building   DaichiBuilding
+		wantOffset int
+	}{
+		{DaichiBuilding{UTC: 3}, 3 * 3600},
+		{DaichiBuilding{UTC: -5}, -5 * 3600},
+		{DaichiBuilding{}, 0},
+		{DaichiBuilding{TimeZone: "UTC", UTC: 7}, 0},
+	}
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		if _, offset := at.In(tt.building.Location()).Zone(); offset != tt.wantOffset {
+			t.Errorf("Location(%+v) offset = %d, want %d", tt.building, offset, tt.wantOffset)
+		}
+	}
+}
+
+func TestDeviceTimesUseBuildingLocation(t *testing.T) {
+	const device = `{"id":1,"buildingId":10,"status":"disconnected","lastOnline":"2024-05-01 12:00:00"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case strings.HasSuffix(path, "/token"):
+			fmt.Fprint(w, `{"done":true,"data":{"access_token":"token"}}`)
+		case strings.HasSuffix(path, "/buildings"):
+			fmt.Fprintf(w, `{"done":true,"data":[{"id":10,"utc":3,"places":[%s]}]}`, device)
+		default:
+			fmt.Fprintf(w, `{"done":true,"data":%s}`, device)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	want := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	buildings, err := c.GetBuildings(ctx)
+	if err != nil {
+		t.Fatalf("GetBuildings: %v", err)
+	}
+	if got := buildings[0].Places[0].LastOnline.Time; !got.Equal(want) {
+		t.Errorf("GetBuildings LastOnline = %s, want %s", got, want)
+	}
+
+	state, err := c.GetDeviceState(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetDeviceState: %v", err)
+	}
+	if got := state.LastOnline.Time; !got.Equal(want) {
+		t.Errorf("GetDeviceState LastOnline = %s, want %s", got, want)
+	}
+}
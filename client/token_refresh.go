@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Параметры фонового обновления токена
+const (
+	DefaultTokenRefreshBefore = time.Minute
+	tokenRefreshTimeout       = 10 * time.Second
+	minTokenRefreshDelay      = 5 * time.Second // Не чаще, даже если токен живет меньше refreshBefore
+	maxTokenRefreshRetryDelay = 5 * time.Minute // Предел паузы между неудачными обновлениями
+)
+
+// WithTokenRefreshBefore — за сколько до истечения обновлять токен в фоне (0 — отключить)
+func WithTokenRefreshBefore(d time.Duration) Option {
+	return func(c *DaichiClient) {
+		c.refreshBefore = d
+	}
+}
+
+// tokenExpiry — определяет срок действия токена по expires_in или claim exp в JWT
+func tokenExpiry(token string, expiresIn int64) time.Time {
+	if expiresIn > 0 {
+		return time.Now().Add(time.Duration(expiresIn) * time.Second)
+	}
+	return jwtExpiry(token)
+}
+
+// jwtExpiry — извлекает claim exp из JWT без проверки подписи
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
+
+// TokenExpiresAt — время истечения текущего токена (нулевое, если неизвестно)
+func (c *DaichiClient) TokenExpiresAt() time.Time {
+	c.tokenMutex.RLock()
+	defer c.tokenMutex.RUnlock()
+	return c.tokenExpiresAt
+}
+
+// setToken — сохраняет токен и планирует его фоновое обновление
+func (c *DaichiClient) setToken(token string, expiresAt time.Time) {
+	c.tokenMutex.Lock()
+	c.token = token
+	c.tokenExpiresAt = expiresAt
+	c.tokenMutex.Unlock()
+
+	c.scheduleRefresh(expiresAt)
+}
+
+// scheduleRefresh — запускает таймер обновления незадолго до истечения токена
+func (c *DaichiClient) scheduleRefresh(expiresAt time.Time) {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+
+	c.refreshFailures = 0
+	if expiresAt.IsZero() {
+		c.stopRefreshTimer()
+		return
+	}
+	c.startRefreshTimer(refreshDelay(time.Until(expiresAt), c.refreshBefore))
+}
+
+// refreshDelay — пауза до фонового обновления токена со сроком lifetime.
+// Если токен живет не дольше refreshBefore, он обновляется в середине срока,
+// но не чаще minTokenRefreshDelay.
+func refreshDelay(lifetime, refreshBefore time.Duration) time.Duration {
+	delay := lifetime - refreshBefore
+	if delay < minTokenRefreshDelay {
+		delay = max(lifetime/2, minTokenRefreshDelay)
+	}
+	return delay
+}
+
+// refreshRetryDelay — пауза перед повтором после failures неудачных обновлений подряд
+func refreshRetryDelay(failures int) time.Duration {
+	delay := minTokenRefreshDelay
+	for i := 1; i < failures && delay < maxTokenRefreshRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxTokenRefreshRetryDelay)
+}
+
+// startRefreshTimer — заменяет таймер фонового обновления; вызывается под refreshMutex
+func (c *DaichiClient) startRefreshTimer(delay time.Duration) {
+	c.stopRefreshTimer()
+	if c.closed || c.refreshBefore <= 0 || !c.hasCredentials() {
+		return
+	}
+
+	c.Logger.Debug("Token refresh scheduled in %s", delay.Round(time.Second))
+	c.refreshTimer = time.AfterFunc(delay, c.backgroundRefresh)
+}
+
+// stopRefreshTimer — останавливает таймер фонового обновления; вызывается под refreshMutex
+func (c *DaichiClient) stopRefreshTimer() {
+	if c.refreshTimer != nil {
+		c.refreshTimer.Stop()
+		c.refreshTimer = nil
+	}
+}
+
+// backgroundRefresh — обновляет токен по таймеру; после ошибки повторяет с растущей паузой
+func (c *DaichiClient) backgroundRefresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenRefreshTimeout)
+	defer cancel()
+
+	c.Logger.Info("Refreshing token before expiry...")
+	_, err := c.refreshOnce(ctx, c.GetToken)
+	if err == nil {
+		return
+	}
+
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+	c.refreshFailures++
+	delay := refreshRetryDelay(c.refreshFailures)
+	c.Logger.Warn("Background token refresh failed, retrying in %s: %v", delay, err)
+	c.startRefreshTimer(delay)
+}
+
+// Close — останавливает фоновое обновление токена
+func (c *DaichiClient) Close() {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+
+	c.closed = true
+	c.stopRefreshTimer()
+}
+
+// refreshCall — обновление токена, которое ожидают несколько горутин
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// refreshOnce — выполняет fn, если обновление еще не идет; иначе ждет текущее.
+// Все ожидающие получают один и тот же токен или одну и ту же ошибку.
+// Общее обновление не зависит от отмены ctx вызвавшего его запроса: каждый
+// ожидающий прекращает ждать только по своему контексту.
+func (c *DaichiClient) refreshOnce(ctx context.Context, fn func(context.Context) error) (string, error) {
+	c.inflightMutex.Lock()
+	call := c.inflight
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		c.inflight = call
+		go c.runRefresh(ctx, call, fn)
+	}
+	c.inflightMutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// runRefresh — выполняет общее обновление с собственным тайм-аутом
+func (c *DaichiClient) runRefresh(ctx context.Context, call *refreshCall, fn func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tokenRefreshTimeout)
+	defer cancel()
+
+	if call.err = fn(ctx); call.err == nil {
+		call.token = c.currentToken()
+	}
+
+	c.inflightMutex.Lock()
+	c.inflight = nil
+	c.inflightMutex.Unlock()
+	close(call.done)
+}
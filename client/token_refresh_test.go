@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// expiringTokenServer — сервер, который выдает новый токен на каждый /token
+// и отвечает 401 на запросы со старым токеном
+type expiringTokenServer struct {
+	*httptest.Server
+	tokenCalls atomic.Int32
+	valid      atomic.Value // string
+}
+
+func newExpiringTokenServer(t *testing.T) *expiringTokenServer {
+	t.Helper()
+	s := &expiringTokenServer{}
+	s.valid.Store("")
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/token") {
+			n := s.tokenCalls.Add(1)
+			// Задержка расширяет окно гонки между параллельными обновлениями
+			time.Sleep(20 * time.Millisecond)
+			token := fmt.Sprintf("token-%d", n)
+			s.valid.Store(token)
+			fmt.Fprintf(w, `{"done":true,"data":{"access_token":%q}}`, token)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+s.valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"done":true,"data":{"id":1,"status":"connected"}}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// expire — делает текущий токен недействительным
+func (s *expiringTokenServer) expire() {
+	s.valid.Store("expired")
+}
+
+func TestConcurrentRequestsRefreshTokenOnce(t *testing.T) {
+	srv := newExpiringTokenServer(t)
+	ctx := context.Background()
+
+	c, err := NewAuthorizedDaichiClient(ctx, "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	if got := srv.tokenCalls.Load(); got != 1 {
+		t.Fatalf("token calls after login = %d, want 1", got)
+	}
+
+	srv.expire()
+
+	const workers = 100
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetDeviceState(ctx, 1); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("GetDeviceState: %v", err)
+	}
+	if refreshes := srv.tokenCalls.Load() - 1; refreshes != 1 {
+		t.Fatalf("token refreshes = %d, want exactly 1", refreshes)
+	}
+}
+
+// testJWT — неподписанный JWT с заданными claims
+func testJWT(claims string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(claims)) + ".signature"
+}
+
+func TestJWTExpiry(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  time.Time
+	}{
+		{"exp", testJWT(`{"exp":1714564800}`), time.Unix(1714564800, 0)},
+		{"fractional exp", testJWT(`{"exp":1714564800.7}`), time.Unix(1714564800, 0)},
+		{"padded payload", strings.Replace(testJWT(`{"exp":1714564800}`), ".sig", "==.sig", 1), time.Unix(1714564800, 0)},
+		{"no exp", testJWT(`{"sub":"user"}`), time.Time{}},
+		{"negative exp", testJWT(`{"exp":-1}`), time.Time{}},
+		{"not a JWT", "opaque-token", time.Time{}},
+		{"bad base64", "a.!!!.c", time.Time{}},
+		{"bad JSON", testJWT(`not json`), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		if got := jwtExpiry(tt.token); !got.Equal(tt.want) {
+			t.Errorf("%s: jwtExpiry = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	jwt := testJWT(`{"exp":1714564800}`)
+
+	if got := tokenExpiry(jwt, 0); !got.Equal(time.Unix(1714564800, 0)) {
+		t.Errorf("without expires_in = %s, want JWT exp", got)
+	}
+	if got := time.Until(tokenExpiry(jwt, 3600)); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("expires_in=3600 expires in %s, want about an hour", got)
+	}
+	if got := tokenExpiry("opaque-token", 0); !got.IsZero() {
+		t.Errorf("opaque token without expires_in = %s, want zero", got)
+	}
+}
+
+func TestRefreshDelay(t *testing.T) {
+	tests := []struct {
+		lifetime, refreshBefore, want time.Duration
+	}{
+		{time.Hour, time.Minute, 59 * time.Minute},
+		{30 * time.Second, time.Minute, 15 * time.Second},
+		{time.Minute, time.Minute, 30 * time.Second},
+		{4 * time.Second, time.Minute, minTokenRefreshDelay},
+		{-time.Minute, time.Minute, minTokenRefreshDelay},
+	}
+
+	for _, tt := range tests {
+		if got := refreshDelay(tt.lifetime, tt.refreshBefore); got != tt.want {
+			t.Errorf("refreshDelay(%s, %s) = %s, want %s", tt.lifetime, tt.refreshBefore, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshRetryDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, minTokenRefreshDelay},
+		{2, 2 * minTokenRefreshDelay},
+		{3, 4 * minTokenRefreshDelay},
+		{10, maxTokenRefreshRetryDelay},
+		{1000, maxTokenRefreshRetryDelay},
+	}
+
+	for _, tt := range tests {
+		if got := refreshRetryDelay(tt.failures); got != tt.want {
+			t.Errorf("refreshRetryDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestShortLivedTokenDoesNotRefreshInLoop(t *testing.T) {
+	var tokenCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenCalls.Add(1)
+		fmt.Fprintf(w, `{"done":true,"data":{"access_token":"token-%d","expires_in":30}}`, n)
+	}))
+	defer srv.Close()
+
+	c, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	time.Sleep(200 * time.Millisecond)
+	if n := tokenCalls.Load(); n != 1 {
+		t.Errorf("token calls = %d, want 1 (refresh is due halfway through the 30s lifetime)", n)
+	}
+}
+
+func TestRefreshSurvivesLeaderCancellation(t *testing.T) {
+	var tokenCalls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := tokenCalls.Add(1)
+		if n > 1 {
+			time.Sleep(100 * time.Millisecond) // Обновление дольше, чем живет контекст лидера
+		}
+		fmt.Fprintf(w, `{"done":true,"data":{"access_token":"token-%d"}}`, n)
+	}))
+	defer srv.Close()
+
+	c, err := NewAuthorizedDaichiClient(context.Background(), "user@example.com", "password", WithBaseURL(srv.URL), WithNoLogs())
+	if err != nil {
+		t.Fatalf("NewAuthorizedDaichiClient: %v", err)
+	}
+	defer c.Close()
+
+	leaderCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.refreshToken(leaderCtx)
+		leaderErr <- err
+	}()
+
+	// Ведомый присоединяется к уже идущему обновлению
+	for {
+		c.inflightMutex.Lock()
+		started := c.inflight != nil
+		c.inflightMutex.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	token, err := c.refreshToken(context.Background())
+	if err != nil || token != "token-2" {
+		t.Errorf("follower: token = %q, err = %v, want token-2", token, err)
+	}
+	if err := <-leaderErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("leader err = %v, want context.DeadlineExceeded", err)
+	}
+	if n := tokenCalls.Load(); n != 2 {
+		t.Errorf("token calls = %d, want 2", n)
+	}
+}
@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StoredToken — сохраненная сессия
+type StoredToken struct {
+	Account     string    `json:"account"` // Логин, для которого выдан токен
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"` // Нулевое значение — срок неизвестен
+}
+
+// Valid — проверяет, что токен есть и срок его действия не истек
+func (t *StoredToken) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.ExpiresAt.IsZero() || time.Now().Before(t.ExpiresAt)
+}
+
+// TokenStore — хранилище токена между запусками процесса
+type TokenStore interface {
+	// Load возвращает ErrTokenNotStored, если токена нет
+	Load(ctx context.Context) (*StoredToken, error)
+	Save(ctx context.Context, token *StoredToken) error
+	Clear(ctx context.Context) error
+}
+
+// WithTokenStore — устанавливает хранилище токена
+func WithTokenStore(store TokenStore) Option {
+	return func(c *DaichiClient) {
+		c.tokenStore = store
+	}
+}
+
+// MemoryTokenStore — хранилище токена в памяти процесса
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *StoredToken
+}
+
+// NewMemoryTokenStore — создает хранилище в памяти
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load реализует TokenStore
+func (s *MemoryTokenStore) Load(_ context.Context) (*StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == nil {
+		return nil, ErrTokenNotStored
+	}
+	token := *s.token
+	return &token, nil
+}
+
+// Save реализует TokenStore
+func (s *MemoryTokenStore) Save(_ context.Context, token *StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *token
+	s.token = &stored
+	return nil
+}
+
+// Clear реализует TokenStore
+func (s *MemoryTokenStore) Clear(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = nil
+	return nil
+}
+
+// FileTokenStore — хранилище токена в файле с правами 0600
+type FileTokenStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTokenStore — создает файловое хранилище
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load реализует TokenStore
+func (s *FileTokenStore) Load(_ context.Context) (*StoredToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrTokenNotStored
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	var token StoredToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token file: %w", err)
+	}
+	return &token, nil
+}
+
+// Save реализует TokenStore; файл записывается атомарно через временный файл
+func (s *FileTokenStore) Save(_ context.Context, token *StoredToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set token file permissions: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close token file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace token file: %w", err)
+	}
+	return nil
+}
+
+// Clear реализует TokenStore
+func (s *FileTokenStore) Clear(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove token file: %w", err)
+	}
+	return nil
+}
+
+// loadStoredToken — восстанавливает токен из хранилища, если он действителен
+func (c *DaichiClient) loadStoredToken(ctx context.Context) bool {
+	if c.tokenStore == nil {
+		return false
+	}
+
+	stored, err := c.tokenStore.Load(ctx)
+	if err != nil {
+		if !errors.Is(err, ErrTokenNotStored) {
+			c.Logger.Warn("Failed to load stored token: %v", err)
+		}
+		return false
+	}
+	if !stored.Valid() {
+		c.Logger.Info("Stored token expired")
+		return false
+	}
+
+	// Токен другого аккаунта (или сохраненный без аккаунта) не используется
+	creds, err := c.credentials(ctx)
+	if err != nil || stored.Account != creds.Username {
+		c.Logger.Info("Stored token belongs to another account")
+		return false
+	}
+
+	expiresAt := stored.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = jwtExpiry(stored.AccessToken)
+	}
+	c.setToken(stored.AccessToken, expiresAt)
+
+	c.Logger.Info("Using stored token")
+	return true
+}
+
+// saveToken — сохраняет токен в хранилище, если оно задано
+func (c *DaichiClient) saveToken(ctx context.Context, token *StoredToken) {
+	if c.tokenStore == nil {
+		return
+	}
+	if err := c.tokenStore.Save(ctx, token); err != nil {
+		c.Logger.Warn("Failed to save token: %v", err)
+	}
+}
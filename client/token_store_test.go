@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStoreRoundTrip(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx); !errors.Is(err, ErrTokenNotStored) {
+		t.Fatalf("Load before Save err = %v, want ErrTokenNotStored", err)
+	}
+
+	want := &StoredToken{Account: "user@example.com", AccessToken: "token", ExpiresAt: time.Now().Add(time.Hour).Round(0)}
+	if err := store.Save(ctx, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil || got.Account != want.Account || got.AccessToken != want.AccessToken || !got.ExpiresAt.Equal(want.ExpiresAt) {
+		t.Fatalf("Load = %+v, %v, want %+v", got, err, want)
+	}
+
+	if err := store.Clear(ctx); err != nil {
+		t.Fatalf("Clear: %v", err)
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrTokenNotStored) {
+		t.Errorf("Load after Clear err = %v, want ErrTokenNotStored", err)
+	}
+}
+
+func TestFileTokenStoreMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "token.json")
+	store := NewFileTokenStore(path)
+	ctx := context.Background()
+
+	// Права сохраняются и при перезаписи существующего файла
+	for i := 0; i < 2; i++ {
+		if err := store.Save(ctx, &StoredToken{Account: "user@example.com", AccessToken: "token"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("save %d: mode = %o, want 600", i+1, mode)
+		}
+	}
+}
+
+func TestFileTokenStoreFailedWriteLeavesNoTempFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token.json")
+	// Вместо файла токена — непустой каталог, поэтому замена файла не удается
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewFileTokenStore(path)
+	if err := store.Save(context.Background(), &StoredToken{AccessToken: "token"}); err == nil {
+		t.Fatal("Save succeeded, want error")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "token.json" {
+			t.Errorf("unexpected file %s left after failed Save", entry.Name())
+		}
+	}
+}
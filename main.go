@@ -18,9 +18,6 @@ func main() {
 		MaxRequests: 5,
 		Interval:    30 * time.Second,
 		Timeout:     10 * time.Second,
-		IsError: func(err error) bool {
-			return err != nil
-		},
 	})
 
 	// Создаем клиент
@@ -31,6 +28,7 @@ func main() {
 		client.WithClientID("sOJO7B6SqgaKudTfCzqLAy540cCuDzpI"),
 		client.WithLogger(logger),
 		client.WithCircuitBreaker(breaker),
+		client.WithRateLimit(5, 10),
 		client.WithDebug(false),
 	)
 	if err != nil {
@@ -46,7 +44,7 @@ func main() {
 	// Выводим MQTT-данные
 	if userInfo.MQTTUser != nil {
 		fmt.Printf("MQTT Username: %s\n", userInfo.MQTTUser.Username)
-		fmt.Printf("MQTT Password: %s\n", userInfo.MQTTUser.Password)
+		fmt.Printf("MQTT Password: %s\n", userInfo.MQTTUser.RedactedPassword())
 	} else {
 		log.Println("MQTTUser is nil — проверьте, что /user возвращает данные")
 	}
@@ -56,6 +54,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to fetch buildings: %v", err)
 	}
+	health := client.BreakerHealth()
+	log.Printf("Circuit breaker: %v, requests: %d, failures: %d, rejected: %d",
+		health.State, health.Requests, health.Failures, health.Rejected)
 
 	// Получаем и выводим состояние всех устройств
 	for _, building := range buildings {